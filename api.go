@@ -2,15 +2,19 @@ package iam
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
-	"github.com/utilslab/iam/binding"
 	"github.com/utilslab/iam/exporter"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,16 +25,42 @@ func New() *API {
 }
 
 type API struct {
-	version        string
-	routers        []Router
-	engine         *gin.Engine
-	routeTable     *RouteTable
-	exporter       *exporter.Exporter
-	methods        []*exporter.Method
-	basics         *exporter.BasicTypes
-	models         *exporter.Fields
-	errorWrapper   ErrorWrapper
-	contextWrapper ContextWrapper
+	version          string
+	routers          []Router
+	driver           Driver
+	routeTable       *RouteTable
+	exporter         *exporter.Exporter
+	exporterPrefix   string
+	methods          []*exporter.Method
+	permissions      []*exporter.Permission
+	basics           *exporter.BasicTypes
+	models           *exporter.Fields
+	errorWrapper     ErrorWrapper
+	contextWrapper   ContextWrapper
+	authorizer       Authorizer
+	interceptors     []Interceptor
+	encoders         map[string]Encoder
+	envelope         *Envelope
+	messages         map[string]map[string]string
+	principalLoader  PrincipalLoader
+	checkOrigin      func(r *http.Request) bool
+	simulateEndpoint bool
+	routes           []*Route
+	actions          []*Action
+	prepared         bool
+	report           *ValidationError
+	endpoints        []*Endpoint
+	printRoutes      bool
+	registered       bool
+	addr             string
+	shutdownTimeout  time.Duration
+	onStart          []Hook
+	onStop           []Hook
+	mu               sync.Mutex // 保护 servers、stopping 与 stopped
+	servers          []*http.Server
+	stopping         bool
+	stopOnce         sync.Once
+	stopped          chan struct{}
 }
 
 func (p *API) SetVersion(version string) {
@@ -41,8 +71,24 @@ func (p *API) AddRouter(router ...Router) {
 	p.routers = append(p.routers, router...)
 }
 
+// SetEngine 使用指定的 gin 引擎作为路由驱动
 func (p *API) SetEngine(engine *gin.Engine) {
-	p.engine = engine
+	p.driver = NewGinDriver(engine)
+}
+
+// SetDriver 设置路由驱动，默认为基于 gin.Default() 的 GinDriver
+func (p *API) SetDriver(driver Driver) {
+	p.driver = driver
+}
+
+// SetPrintRoutes 设置启动时是否打印路由表
+func (p *API) SetPrintRoutes(print bool) {
+	p.printRoutes = print
+}
+
+// RouteTable 返回已注册的路由表
+func (p *API) RouteTable() *RouteTable {
+	return p.routeTable
 }
 
 func (p *API) SetContextWrapper(contextWrapper ContextWrapper) {
@@ -53,6 +99,10 @@ func (p *API) SetErrorWrapper(errorWrapper ErrorWrapper) {
 	p.errorWrapper = errorWrapper
 }
 
+func (p *API) SetAuthorizer(authorizer Authorizer) {
+	p.authorizer = authorizer
+}
+
 func (p *API) SetExporter(addr string, options *exporter.Options) {
 	basicTypes := []exporter.BasicType{
 		{
@@ -93,35 +143,74 @@ func (p *API) SetExporter(addr string, options *exporter.Options) {
 	p.exporter = exporter.NewExporter(addr, options)
 }
 
-func (p *API) Run(addr string) {
-	if p.engine == nil {
-		p.engine = gin.Default()
-	}
-	var (
-		routes []*Route
-		err    error
-	)
-	for _, router := range p.routers {
-		routes = router.Routes()
-		err = p.prepareRoutes(routes)
+// ExportProtocol 作为 Export 的 lang 参数时导出接口描述协议 protocol.json，可供命令行离线生成 SDK 与 OpenAPI 文档
+const ExportProtocol = "protocol"
+
+// Export 预处理路由并在进程内生成 lang 对应的 SDK 到 dir 目录，不启动任何服务，可用于 go generate 及无网络的 CI 环境
+//
+// 需先调用 SetExporter，lang 可为内置及 Options.Makers 注册的生成目标，或 ExportProtocol
+func (p *API) Export(dir, lang string) (err error) {
+	if p.exporter == nil {
+		return fmt.Errorf("exporter not set, call SetExporter first")
+	}
+	if err = p.prepare(); err != nil {
+		return
+	}
+	var files []*exporter.File
+	if lang == ExportProtocol {
+		var data []byte
+		data, err = json.MarshalIndent(p.exporter.Protocol(""), "", "  ")
 		if err != nil {
-			panic(err)
+			return
 		}
-		err = p.registerRoutes(p.engine, routes)
+		files = append(files, &exporter.File{Name: "protocol.json", Content: string(data)})
+	} else {
+		files, err = p.exporter.SDK(lang, "")
 		if err != nil {
-			panic(err)
 			return
 		}
 	}
-	if p.exporter != nil {
-		p.exporter.Init(p.version, p.methods, p.models)
-		p.exporter.Run()
-	}
-	err = p.engine.Run(addr)
-	if err != nil {
-		panic(err)
+	if err = os.MkdirAll(dir, 0755); err != nil {
 		return
 	}
+	for _, v := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, v.Name), []byte(v.Content), 0644); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Run 在 addr 上启动服务，等同于 SetAddr 后调用 Start
+func (p *API) Run(addr string) error {
+	p.addr = addr
+	return p.Start(context.Background())
+}
+
+// Validate 预处理并校验全部路由，返回包含所有问题的 *ValidationError
+func (p *API) Validate() error {
+	if !p.prepared {
+		for _, router := range p.routers {
+			p.routes = append(p.routes, router.Routes()...)
+		}
+		p.report = new(ValidationError)
+		p.prepareRoutes(p.routes, p.report)
+		p.validateRoutes(p.routes, p.report)
+		p.prepared = true
+	}
+	if len(p.report.Issues) > 0 {
+		return p.report
+	}
+	return nil
+}
+
+// 检查输出是否为不经编码的 Html、Text、File、Stream 及事件流
+func (p *API) isRaw(t reflect.Type) bool {
+	if t.NumOut() < 2 {
+		return false
+	}
+	out := t.Out(0)
+	return out == reflect.TypeOf(Html("")) || out == reflect.TypeOf(Text("")) || isBinary(out) || isStream(out)
 }
 
 // 检查参数是否为 error 类型
@@ -173,165 +262,92 @@ func (p *API) isHandler(t reflect.Type) error {
 func (p *API) parseHandler(handler interface{}) (v reflect.Value, err error) {
 	v = reflect.ValueOf(handler)
 	if err = p.isHandler(v.Type()); err != nil {
-		err = fmt.Errorf("unexpect handler: %s", v.Type())
+		err = fmt.Errorf("unexpect handler %s: %s", v.Type(), err)
 		return
 	}
 	return
 }
 
 // 预处理路由，反射路由处理器，并检查类型
-func (p *API) prepareRoutes(routes []*Route) (err error) {
-	// TODO Action 冲突检查
+func (p *API) prepareRoutes(routes []*Route, report *ValidationError) {
 	for _, route := range routes {
 		for _, group := range route.Groups {
 			for _, action := range group.Actions {
-				err = p.prepareAction(action)
-				if err != nil {
-					return
+				action.group = group.Name
+				if err := p.prepareAction(action); err != nil {
+					report.add(action, "%s", err)
+					continue
 				}
+				p.actions = append(p.actions, action)
 			}
 		}
 	}
-	return
 }
 
 func (p *API) prepareAction(action *Action) (err error) {
-	switch action.Type {
-	case Read, List:
+	switch {
+	case action.Method != "":
+		action.method = strings.ToUpper(action.Method)
+	case action.Type == Read, action.Type == List:
 		action.method = Get
 	default:
 		action.method = Post
 	}
-	if action.Handler != nil {
-		action.handler, err = p.parseHandler(action.Handler)
-	} else {
-		// TODO 报错，Handler 未定义
-		err = fmt.Errorf("action Handle not defined")
+	if action.Handler == nil {
+		err = fmt.Errorf("action Handler not defined")
 		return
 	}
-	return
-}
-
-// 递归注册路由树，处理中间件前缀逻辑，代理路由处理器为 Gin 控制器
-func (p *API) registerRoutes(register Register, routes []*Route) (err error) {
-	for _, route := range routes {
-		routeRegister := register
-		if route.Prefix != "" || len(route.Middlewares) > 0 {
-			routeRegister = register.Group(route.Prefix, route.Middlewares...)
-		}
-		for _, group := range route.Groups {
-			groupRegister := routeRegister
-			if group.Prefix != "" || len(group.Middlewares) > 0 {
-				groupRegister = routeRegister.Group(group.Prefix, group.Middlewares...)
-			}
-			for _, action := range group.Actions {
-				info := p.parseHandlerInfo(action.Handler)
-				path := info.ParsePath()
-				fullPath := strings.Join([]string{route.Prefix, group.Prefix, path}, "")
-				p.addMethod(action.method, fullPath, action.Description, info, action.handler)
-				switch action.method {
-				case http.MethodGet:
-					groupRegister.GET(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				case http.MethodPost:
-					groupRegister.POST(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				case http.MethodPut:
-					groupRegister.PUT(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				case http.MethodDelete:
-					groupRegister.DELETE(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				case http.MethodHead:
-					groupRegister.HEAD(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				case http.MethodOptions:
-					groupRegister.OPTIONS(path, append([]gin.HandlerFunc{p.proxyHandler(action.handler)}, route.Middlewares...)...)
-				default:
-					err = fmt.Errorf("action '%s' method '%s' unsupported", info.Name, action.method)
-					return
-				}
-			}
-		}
+	if reflect.TypeOf(action.Handler).Kind() == reflect.Func {
+		info := p.parseHandlerInfo(action.Handler)
+		action.name = info.Name
+		action.location = info.Location
+	}
+	action.handler, err = p.parseHandler(action.Handler)
+	if err != nil {
+		return
+	}
+	action.path = action.Path
+	if action.path == "" {
+		action.path = HandlerInfo{Name: action.name}.ParsePath()
 	}
 	return
 }
 
-func (p *API) proxyHandler(handler reflect.Value) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var out []reflect.Value
-		var ctx context.Context
-		var err error
-		defer func() {
-			if err != nil {
-				if p.errorWrapper != nil {
-					p.errorWrapper(c, err)
-				} else {
-					c.String(http.StatusBadRequest, err.Error())
-				}
-			}
-			return
-		}()
-		if p.contextWrapper == nil {
-			ctx = context.Background()
-		} else {
-			ctx, err = p.contextWrapper(c)
-			if err != nil {
-				return
-			}
-		}
-		if handler.Type().NumIn() == 2 {
-			var in reflect.Value
-			in, err = bind(c, handler.Type().In(1))
-			if err != nil {
-				return
-			}
-			out = handler.Call([]reflect.Value{reflect.ValueOf(ctx), in})
-		} else {
-			out = handler.Call([]reflect.Value{reflect.ValueOf(ctx)})
-		}
-
-		l := len(out)
-		if !out[l-1].IsNil() {
-			err = out[l-1].Interface().(error)
-			return
-		}
-		if l == 2 {
-			switch out[0].Interface().(type) {
-			case Html:
-				c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(out[0].Interface().(Html)))
-			case Text:
-				c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(out[0].Interface().(Text)))
-			default:
-				c.JSON(http.StatusOK, out[0].Interface())
-			}
-			return
-		} else {
-			c.String(http.StatusOK, "")
-			return
-		}
+// 收集导出器方法、权限目录与路由表
+func (p *API) collect() {
+	p.registerTypes()
+	for _, endpoint := range p.endpoints {
+		action := endpoint.Action
+		info := p.parseHandlerInfo(action.Handler)
+		p.addMethod(action, endpoint.Path, info)
+		p.addPermission(action, endpoint.Path)
+		p.routeTable.AddRow(action.method, endpoint.Path, info, action)
 	}
-}
-
-func realType(t reflect.Type) reflect.Type {
-	for {
-		if t.Kind() != reflect.Ptr {
-			return t
-		}
-		t = t.Elem()
+	if p.exporter != nil {
+		p.models = p.exporter.Models()
 	}
 }
 
-func bind(c *gin.Context, t reflect.Type) (reflect.Value, error) {
-	ptr := t.Kind() == reflect.Ptr
-	if ptr {
-		t = realType(t)
-	}
-	in := reflect.New(t)
-	b := binding.Default(c.Request.Method, c.ContentType())
-	err := c.MustBindWith(in.Interface(), b)
-	if err != nil {
-		return in, err
+// 预先登记全部入参、出参类型，以便识别不同包的同名结构体
+func (p *API) registerTypes() {
+	if p.exporter == nil {
+		return
 	}
-	if ptr {
-		return in, nil
+	types := []reflect.Type{fieldErrorsType}
+	for _, endpoint := range p.endpoints {
+		t := endpoint.Action.handler.Type()
+		if t.NumIn() > 1 {
+			types = append(types, t.In(1))
+		}
+		if t.NumOut() > 1 {
+			if out := t.Out(0); isStream(out) {
+				types = append(types, out.Elem())
+			} else if !isBinary(out) {
+				types = append(types, out)
+			}
+		}
 	}
-	return in.Elem(), nil
+	p.exporter.RegisterTypes(types...)
 }
 
 // 解析 Handler 的信息
@@ -358,21 +374,74 @@ func (p *API) parseHandlerInfoValue(v reflect.Value) HandlerInfo {
 	}
 }
 
-func (p *API) addMethod(method, path, description string, info HandlerInfo, handler reflect.Value) {
+func (p *API) exporterRoutes() (routes []*exporter.Route) {
+	for _, v := range p.routeTable.Rows() {
+		routes = append(routes, &exporter.Route{
+			Method:     v.Method,
+			Path:       v.Path,
+			Handler:    v.Source,
+			Location:   v.Location,
+			Permission: v.Permission,
+			Type:       v.Type,
+			Resource:   v.Resource,
+		})
+	}
+	return
+}
+
+// 有入参的方法均可能返回入参校验失败错误，未声明时补充该错误码及其字段错误详情结构
+func (p *API) addInvalidParamsCode(codes []*exporter.Code) []*exporter.Code {
+	for _, v := range codes {
+		if v.Code == CodeInvalidParams {
+			return codes
+		}
+	}
+	return append(codes, &exporter.Code{
+		Status:  http.StatusBadRequest,
+		Code:    CodeInvalidParams,
+		Message: "invalid params",
+		Details: p.exporter.ReflectFields("", "", "", nil, nil, fieldErrorsType),
+	})
+}
+
+// Action 的描述，未声明时取 Handler 的文档注释，参见 exporter.RegisterDocs
+func (p *API) description(action *Action) string {
+	if action.Description != "" {
+		return action.Description
+	}
+	return exporter.FuncDoc(action.Handler)
+}
+
+func (p *API) addMethod(action *Action, path string, info HandlerInfo) {
 	if p.exporter == nil {
 		return
 	}
+	handler := action.handler
 	m := &exporter.Method{
 		Name:        info.Name,
 		Path:        path,
-		Method:      method,
-		Description: description,
+		Method:      action.method,
+		Description: p.description(action),
+	}
+	if p.envelope != nil && !p.isRaw(handler.Type()) {
+		m.Envelope = p.envelope.exporter()
+	}
+	for _, v := range action.Codes {
+		m.Codes = append(m.Codes, &exporter.Code{Status: v.Status, Code: v.Code, Message: v.Message})
 	}
 	if handler.Type().NumIn() > 1 {
 		m.Input = p.exporter.ReflectFields("", "", "", nil, nil, handler.Type().In(1))
+		m.Multipart = m.Input.HasFile()
+		m.Codes = p.addInvalidParamsCode(m.Codes)
 	}
 	if handler.Type().NumOut() > 1 {
-		m.Output = p.exporter.ReflectFields("", "", "", nil, nil, handler.Type().Out(0))
+		if out := handler.Type().Out(0); isBinary(out) {
+			m.Binary = true
+		} else if isStream(out) {
+			m.Event = p.exporter.ReflectFields("", "", "", nil, nil, out.Elem())
+		} else {
+			m.Output = p.exporter.ReflectFields("", "", "", nil, nil, handler.Type().Out(0))
+		}
 	}
 	p.methods = append(p.methods, m)
 }
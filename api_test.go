@@ -0,0 +1,59 @@
+package iam
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = ioutil.Discard
+}
+
+type testRouter []*Route
+
+func (p testRouter) Routes() []*Route {
+	return p
+}
+
+// 以单个分组构造 API
+func newTestAPI(group string, actions ...*Action) *API {
+	api := New()
+	api.AddRouter(testRouter{{Groups: []*Group{{Name: group, Actions: actions}}}})
+	return api
+}
+
+func serve(t *testing.T, api *API, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	handler, err := api.Handler()
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+type testShopIn struct {
+	ShopId int64  `json:"shopId" form:"shopId"`
+	CateId int64  `json:"cateId" form:"cateId"`
+	Color  string `json:"color" form:"color"`
+}
+
+type testShopOut struct {
+	ShopId int64  `json:"shopId"`
+	Color  string `json:"color"`
+}
+
+type testShopService struct {
+	calls int
+}
+
+func (p *testShopService) GetShop(ctx context.Context, in *testShopIn) (*testShopOut, error) {
+	p.calls++
+	return &testShopOut{ShopId: in.ShopId, Color: in.Color}, nil
+}
@@ -0,0 +1,279 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/utilslab/iam/exporter"
+	"github.com/utilslab/iam/policy"
+	"github.com/utilslab/iam/utils"
+	"reflect"
+	"strings"
+)
+
+// Authorizer 鉴权器，判断上下文中的调用者能否对资源执行 Action
+//
+// 调用者(Principal)由 ContextWrapper 注入上下文，鉴权器自行从 ctx 中读取
+type Authorizer interface {
+	Authorize(ctx context.Context, req *AuthRequest) (allowed bool, err error)
+}
+
+// AuthorizerFunc 函数形式的鉴权器
+type AuthorizerFunc func(ctx context.Context, req *AuthRequest) (bool, error)
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *AuthRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+// AuthRequest 描述一次鉴权请求
+type AuthRequest struct {
+	Group    string            // Action 所属分组名称
+	Action   string            // Action 处理器名称
+	Type     ActionType        // Action 类型
+	Resource string            // 由 Action.Resources 解析出的资源名称，如 shop/42/cate/7，未声明资源时为空
+	Scope    map[string]string // 资源范围变量取值，如 $color
+}
+
+// Permission 返回 Action 权限名称，形如 分组:方法名
+func (p AuthRequest) Permission() string {
+	return permissionName(p.Group, p.Action)
+}
+
+func permissionName(group, name string) string {
+	if group == "" {
+		return name
+	}
+	return fmt.Sprintf("%s:%s", group, name)
+}
+
+// PolicySource 获取上下文中调用者关联的策略文档
+type PolicySource func(ctx context.Context) ([]*policy.Document, error)
+
+// NewPolicyAuthorizer 基于策略文档的鉴权器
+func NewPolicyAuthorizer(source PolicySource) *PolicyAuthorizer {
+	return &PolicyAuthorizer{source: source}
+}
+
+type PolicyAuthorizer struct {
+	source PolicySource
+}
+
+func (p PolicyAuthorizer) Authorize(ctx context.Context, req *AuthRequest) (allowed bool, err error) {
+	decision, err := p.Evaluate(ctx, req)
+	if err != nil {
+		return
+	}
+	allowed = decision.Allowed
+	return
+}
+
+// Evaluate 返回完整的鉴权结果，包含匹配的语句与条件
+func (p PolicyAuthorizer) Evaluate(ctx context.Context, req *AuthRequest) (decision *policy.Decision, err error) {
+	docs, err := p.source(ctx)
+	if err != nil {
+		return
+	}
+	decision = policy.Evaluate(docs, &policy.Request{
+		Action:   req.Permission(),
+		Type:     string(req.Type),
+		Resource: req.Resource,
+		Scope:    req.Scope,
+	})
+	return
+}
+
+// DeniedError 鉴权未通过，代理处理器以 403 状态返回
+type DeniedError struct {
+	Action   string
+	Resource string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Resource == "" {
+		return fmt.Sprintf("access denied: action '%s'", e.Action)
+	}
+	return fmt.Sprintf("access denied: action '%s' on resource '%s'", e.Action, e.Resource)
+}
+
+// 在调用 Handler 前执行鉴权
+func (p *API) authorize(ctx context.Context, action *Action, in reflect.Value, present presence) (err error) {
+	if p.authorizer == nil {
+		return
+	}
+	req, err := newAuthRequest(action, in, present)
+	if err != nil {
+		return
+	}
+	allowed, err := p.authorizer.Authorize(ctx, req)
+	if err != nil {
+		return
+	}
+	if !allowed {
+		err = &DeniedError{Action: action.name, Resource: req.Resource}
+		return
+	}
+	return
+}
+
+func newAuthRequest(action *Action, in reflect.Value, present presence) (req *AuthRequest, err error) {
+	req = &AuthRequest{
+		Group:  action.group,
+		Action: action.name,
+		Type:   action.Type,
+		Scope:  map[string]string{},
+	}
+	req.Resource, err = resolveResources(action.Resources, in, present, req.Scope)
+	return
+}
+
+// 从入参中解析资源标识变量，拼接资源名称，可选资源在标识缺失时跳过
+func resolveResources(resources []Resource, in reflect.Value, present presence, scope map[string]string) (name string, err error) {
+	var segments []string
+	for _, resource := range resources {
+		var idents []string
+		for _, ident := range resource.Ident {
+			value, ok := lookupVar(in, ident.Var, present)
+			if !ok {
+				break
+			}
+			idents = append(idents, value)
+		}
+		if len(idents) != len(resource.Ident) {
+			if resource.optional {
+				continue
+			}
+			err = fmt.Errorf("resource '%s' identifier missing", resource.Name)
+			return
+		}
+		segments = append(segments, resource.Name)
+		segments = append(segments, idents...)
+		for _, v := range resource.Scope {
+			if value, ok := lookupVar(in, v.Var, present); ok {
+				scope[v.Var] = value
+			}
+		}
+	}
+	name = strings.Join(segments, "/")
+	return
+}
+
+// presence 判断入参字段是否由请求提供，用于区分缺失的资源标识与零值
+type presence func(f reflect.StructField) bool
+
+// 按 JSON 对象的顶层键判断字段是否提供，与 encoding/json 一致按 json 标签或字段名不区分大小写匹配，null 视为缺失
+func jsonPresence(keys map[string]json.RawMessage) presence {
+	return func(f reflect.StructField) bool {
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		for k, v := range keys {
+			if strings.EqualFold(k, name) && string(v) != "null" {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// 按变量名查找入参字段值，变量 $shopId 可匹配字段 ShopId 或标签名 shopId
+//
+// nil 指针或请求未提供的字段视为缺失，提供的零值仍为有效标识；present 为 nil 时仅按指针判断
+func lookupVar(in reflect.Value, name string, present presence) (value string, ok bool) {
+	if !in.IsValid() {
+		return
+	}
+	in = utils.ValueElem(in)
+	if in.Kind() != reflect.Struct {
+		return
+	}
+	name = strings.TrimPrefix(name, "$")
+	t := in.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if f.Anonymous {
+			if value, ok = lookupVar(in.Field(i), name, present); ok {
+				return
+			}
+			continue
+		}
+		if !matchVar(f, name) {
+			continue
+		}
+		v := in.Field(i)
+		if v.Kind() != reflect.Ptr && present != nil && !present(f) {
+			return
+		}
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		return fmt.Sprint(v.Interface()), true
+	}
+	return
+}
+
+func matchVar(f reflect.StructField, name string) bool {
+	if strings.EqualFold(f.Name, name) {
+		return true
+	}
+	for _, key := range []string{"json", "form", "uri", "query", "header", "cookie"} {
+		if strings.Split(f.Tag.Get(key), ",")[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 拼接资源名称模板，如 shop/$shopId[/cate/$cateId]，[] 内为可选资源
+func resourceTemplate(resources []Resource) string {
+	var b strings.Builder
+	for _, resource := range resources {
+		segment := resource.template()
+		if b.Len() > 0 {
+			segment = "/" + segment
+		}
+		if resource.optional {
+			segment = fmt.Sprintf("[%s]", segment)
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+func (p *API) addPermission(action *Action, path string) {
+	if p.exporter == nil {
+		return
+	}
+	permission := &exporter.Permission{
+		Name:        permissionName(action.group, action.name),
+		Action:      action.name,
+		Group:       action.group,
+		Type:        string(action.Type),
+		Resource:    resourceTemplate(action.Resources),
+		Description: p.description(action),
+		Method:      action.method,
+		Path:        path,
+	}
+	for _, resource := range action.Resources {
+		item := &exporter.PermissionResource{
+			Name:        resource.Name,
+			Template:    resource.template(),
+			Description: resource.Description,
+			Optional:    resource.optional,
+		}
+		for _, v := range resource.Ident {
+			item.Ident = append(item.Ident, &exporter.Variable{Var: v.Var, Description: v.Description})
+		}
+		for _, v := range resource.Scope {
+			item.Scope = append(item.Scope, &exporter.Variable{Var: v.Var, Description: v.Description})
+		}
+		permission.Resources = append(permission.Resources, item)
+	}
+	p.permissions = append(p.permissions, permission)
+}
@@ -0,0 +1,164 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+var (
+	testShopResource = Resource{Name: "shop", Ident: []Field{{Var: "$shopId"}}, Scope: []Field{{Var: "$color"}}}
+	testCateResource = Resource{Name: "cate", Ident: []Field{{Var: "$cateId"}}}
+)
+
+func TestResolveResources(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		resources []Resource
+		input     string
+		want      string
+		scope     map[string]string
+		err       bool
+	}{
+		{"none", nil, `{"shopId":42}`, "", map[string]string{}, false},
+		{"single", []Resource{testShopResource}, `{"shopId":42}`, "shop/42", map[string]string{}, false},
+		{"zero", []Resource{testShopResource}, `{"shopId":0}`, "shop/0", map[string]string{}, false},
+		{"nested", []Resource{testShopResource, testCateResource}, `{"shopId":42,"cateId":7}`, "shop/42/cate/7", map[string]string{}, false},
+		{"optional missing", []Resource{testShopResource, testCateResource.Optional()}, `{"shopId":42}`, "shop/42", map[string]string{}, false},
+		{"required missing", []Resource{testShopResource, testCateResource}, `{"shopId":42}`, "", nil, true},
+		{"null", []Resource{testShopResource}, `{"shopId":null}`, "", nil, true},
+		{"scope", []Resource{testShopResource}, `{"shopId":42,"color":"red"}`, "shop/42", map[string]string{"$color": "red"}, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var in testShopIn
+			var keys map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal([]byte(c.input), &in))
+			require.NoError(t, json.Unmarshal([]byte(c.input), &keys))
+			scope := map[string]string{}
+			name, err := resolveResources(c.resources, reflect.ValueOf(&in), jsonPresence(keys), scope)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, name)
+			assert.Equal(t, c.scope, scope)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var requests []*AuthRequest
+	service := new(testShopService)
+	api := newTestAPI("shop", &Action{Type: Read, Resources: []Resource{testShopResource}, Handler: service.GetShop})
+	api.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req *AuthRequest) (bool, error) {
+		requests = append(requests, req)
+		return req.Resource == "shop/42", nil
+	}))
+	for _, c := range []struct {
+		name   string
+		target string
+		status int
+		calls  int
+	}{
+		{"allowed", "/GetShop?shopId=42&color=red", http.StatusOK, 1},
+		{"denied", "/GetShop?shopId=7", http.StatusForbidden, 1},
+		{"identifier missing", "/GetShop", http.StatusBadRequest, 1},
+		{"zero identifier", "/GetShop?shopId=0", http.StatusForbidden, 1},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			w := serve(t, api, httptest.NewRequest(http.MethodGet, c.target, nil))
+			assert.Equal(t, c.status, w.Code, w.Body.String())
+			assert.Equal(t, c.calls, service.calls)
+		})
+	}
+	if assert.Len(t, requests, 3) {
+		assert.Equal(t, "shop:GetShop", requests[0].Permission())
+		assert.Equal(t, Read, requests[0].Type)
+		assert.Equal(t, map[string]string{"$color": "red"}, requests[0].Scope)
+		// 提供的零值是有效标识，由鉴权器判定
+		assert.Equal(t, "shop/0", requests[2].Resource)
+	}
+}
+
+func TestAuthorizeJSONBody(t *testing.T) {
+	var resources []string
+	service := new(testShopService)
+	api := newTestAPI("shop", &Action{Type: Read, Method: Post, Resources: []Resource{testShopResource}, Handler: service.GetShop})
+	api.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req *AuthRequest) (bool, error) {
+		resources = append(resources, req.Resource)
+		return true, nil
+	}))
+	for _, c := range []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"provided", `{"shopId":42}`, http.StatusOK},
+		{"zero", `{"shopId":0}`, http.StatusOK},
+		{"missing", `{"color":"red"}`, http.StatusBadRequest},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/GetShop", strings.NewReader(c.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := serve(t, api, r)
+			assert.Equal(t, c.status, w.Code, w.Body.String())
+		})
+	}
+	// 提供的零值同样交由鉴权器判定，请求体读取后仍正常调用 Handler
+	assert.Equal(t, []string{"shop/42", "shop/0"}, resources)
+	assert.Equal(t, 2, service.calls)
+}
+
+func TestResourceTemplate(t *testing.T) {
+	for _, c := range []struct {
+		resources []Resource
+		want      string
+	}{
+		{nil, ""},
+		{[]Resource{{Name: "shops"}}, "shops"},
+		{[]Resource{testShopResource}, "shop/$shopId"},
+		{[]Resource{testShopResource, testCateResource}, "shop/$shopId/cate/$cateId"},
+		{[]Resource{testShopResource, testCateResource.Optional()}, "shop/$shopId[/cate/$cateId]"},
+	} {
+		assert.Equal(t, c.want, resourceTemplate(c.resources))
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	service := new(testShopService)
+	api := newTestAPI("shop", &Action{
+		Type:        Read,
+		Description: "获取店铺",
+		Resources:   []Resource{testShopResource, testCateResource.Optional()},
+		Handler:     service.GetShop,
+	})
+	api.SetExporter("", nil)
+	api.SetExporterPrefix("_")
+	w := serve(t, api, httptest.NewRequest(http.MethodGet, "/_/permissions", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var permissions []*exporter.Permission
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &permissions))
+	assert.Equal(t, []*exporter.Permission{{
+		Name:        "shop:GetShop",
+		Action:      "GetShop",
+		Group:       "shop",
+		Type:        "read",
+		Resource:    "shop/$shopId[/cate/$cateId]",
+		Description: "获取店铺",
+		Method:      http.MethodGet,
+		Path:        "/GetShop",
+		Resources: []*exporter.PermissionResource{
+			{Name: "shop", Template: "shop/$shopId", Ident: []*exporter.Variable{{Var: "$shopId"}}, Scope: []*exporter.Variable{{Var: "$color"}}},
+			{Name: "cate", Template: "cate/$cateId", Optional: true, Ident: []*exporter.Variable{{Var: "$cateId"}}},
+		},
+	}}, permissions)
+}
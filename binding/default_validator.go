@@ -80,6 +80,7 @@ func (v *defaultValidator) Engine() interface{} {
 func (v *defaultValidator) lazyinit() {
 	v.once.Do(func() {
 		v.validate = validator.New()
-		v.validate.SetTagName("binding")
+		v.validate.SetTagName(ValidateTag)
+		registerValidations(v.validate)
 	})
 }
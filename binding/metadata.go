@@ -0,0 +1,86 @@
+package binding
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// 仅映射显式声明了标签的字段，避免按字段名从请求头、查询参数等位置误取值
+type taggedSource struct {
+	setter
+	tag string
+}
+
+func (s taggedSource) TrySet(value reflect.Value, field reflect.StructField, tagValue string, opt setOptions) (isSetted bool, err error) {
+	if _, ok := field.Tag.Lookup(s.tag); !ok {
+		return false, nil
+	}
+	return s.setter.TrySet(value, field, tagValue, opt)
+}
+
+// MapQuery 按 query 标签映射查询参数，不做校验
+func MapQuery(obj interface{}, values map[string][]string) error {
+	return mappingByPtr(obj, taggedSource{setter: formSource(values), tag: "query"}, "query")
+}
+
+// MapHeader 按 header 标签映射请求头，不做校验
+func MapHeader(obj interface{}, h map[string][]string) error {
+	return mappingByPtr(obj, taggedSource{setter: headerSource(h), tag: "header"}, "header")
+}
+
+// MapCookie 按 cookie 标签映射 Cookie，不做校验
+func MapCookie(obj interface{}, cookies []*http.Cookie) error {
+	m := make(map[string][]string, len(cookies))
+	for _, v := range cookies {
+		m[v.Name] = append(m[v.Name], v.Value)
+	}
+	return mappingByPtr(obj, taggedSource{setter: formSource(m), tag: "cookie"}, "cookie")
+}
+
+// TaggedValues 按字段顺序返回声明了任一指定标签的字段值，嵌套结构体一并遍历
+func TaggedValues(obj interface{}, tags ...string) (values []interface{}) {
+	walkTagged(reflect.ValueOf(obj), tags, func(v reflect.Value) {
+		values = append(values, v.Interface())
+	})
+	return
+}
+
+// ResetTagged 将声明了任一指定标签的字段置为零值，用于丢弃请求体或表单写入位置字段的值
+func ResetTagged(obj interface{}, tags ...string) {
+	walkTagged(reflect.ValueOf(obj), tags, func(v reflect.Value) {
+		v.Set(reflect.Zero(v.Type()))
+	})
+}
+
+func walkTagged(value reflect.Value, tags []string, fn func(v reflect.Value)) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { // unexported
+			continue
+		}
+		if hasTag(sf, tags) {
+			fn(value.Field(i))
+			continue
+		}
+		walkTagged(value.Field(i), tags, fn)
+	}
+}
+
+func hasTag(field reflect.StructField, tags []string) bool {
+	for _, tag := range tags {
+		if v, ok := field.Tag.Lookup(tag); ok && v != "-" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetTagged(t *testing.T) {
+	type meta struct {
+		Trace string `header:"X-Trace"`
+		Note  string
+	}
+	var s struct {
+		Tenant  string `header:"X-Tenant"`
+		Sid     string `cookie:"sid"`
+		Ignored string `header:"-"`
+		Name    string
+		Meta    *meta
+	}
+	s.Tenant, s.Sid, s.Ignored, s.Name = "t", "s", "i", "n"
+	s.Meta = &meta{Trace: "r", Note: "m"}
+	assert.Equal(t, []interface{}{"t", "s", "r"}, TaggedValues(&s, "header", "cookie"))
+	assert.Equal(t, []interface{}{"s"}, TaggedValues(&s, "cookie"))
+
+	ResetTagged(&s, "header", "cookie")
+	assert.Empty(t, s.Tenant)
+	assert.Empty(t, s.Sid)
+	assert.Equal(t, "i", s.Ignored)
+	assert.Equal(t, "n", s.Name)
+	assert.Equal(t, &meta{Note: "m"}, s.Meta)
+}
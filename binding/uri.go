@@ -16,3 +16,8 @@ func (uriBinding) BindUri(m map[string][]string, obj interface{}) error {
 	}
 	return validate(obj)
 }
+
+// MapUri maps uri params into obj without validating it.
+func MapUri(obj interface{}, m map[string][]string) error {
+	return mapUri(obj, m)
+}
@@ -0,0 +1,43 @@
+package binding
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateTag 校验规则所在的结构体标签，运行时校验与导出器共用
+const ValidateTag = "binding"
+
+var regexps sync.Map
+
+// 注册 go-playground/validator 未内置的规则
+func registerValidations(v *validator.Validate) {
+	_ = v.RegisterValidation("regex", validateRegex)
+}
+
+// RegexParam 还原 regex 规则参数中按 go-playground 约定转义的逗号与竖线
+func RegexParam(param string) string {
+	return strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(param)
+}
+
+// 校验字符串是否匹配正则，如 binding:"regex=^[a-z]+$"，参数中的逗号、竖线需写作 0x2C、0x7C
+func validateRegex(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	pattern := RegexParam(fl.Param())
+	re, ok := regexps.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		re, _ = regexps.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(field.String())
+}
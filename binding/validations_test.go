@@ -0,0 +1,47 @@
+package binding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRegex(t *testing.T) {
+	type code struct {
+		Code  string `binding:"omitempty,regex=^[a-z]{20x2C3}$"`
+		Color string `binding:"omitempty,regex=^(red0x7Cblue)$"`
+		Count int    `binding:"omitempty,regex=^1$"`
+	}
+	for _, c := range []struct {
+		v  code
+		ok bool
+	}{
+		{code{}, true},
+		{code{Code: "ab"}, true},
+		{code{Code: "abc"}, true},
+		{code{Code: "a"}, false},
+		{code{Code: "abcd"}, false},
+		{code{Code: "AB"}, false},
+		{code{Color: "red"}, true},
+		{code{Color: "blue"}, true},
+		{code{Color: "green"}, false},
+		{code{Count: 1}, false},
+	} {
+		err := validate(&c.v)
+		if c.ok {
+			assert.NoError(t, err, "%+v", c.v)
+		} else {
+			assert.Error(t, err, "%+v", c.v)
+		}
+	}
+	assert.Equal(t, "^[a-z]{2,3}$", RegexParam("^[a-z]{20x2C3}$"))
+	assert.Equal(t, "^(a|b)$", RegexParam("^(a0x7Cb)$"))
+}
+
+func TestValidateRegexInvalidPattern(t *testing.T) {
+	var s struct {
+		Code string `binding:"regex=^[a-z$"`
+	}
+	s.Code = "a"
+	assert.Error(t, validate(&s))
+}
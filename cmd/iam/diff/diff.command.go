@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/utilslab/iam/exporter"
+	"io/ioutil"
+)
+
+var Command = &cobra.Command{
+	Use:          "diff <old> <new>",
+	Short:        "比较两份接口描述协议，检查破坏性变更",
+	Long:         "比较两份接口描述协议（即 /protocol 的输出）并输出变更报告，存在破坏性变更时以非零状态码退出",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd, args)
+	},
+}
+
+func init() {
+	Command.Flags().StringP("json", "j", "", "指定 JSON 报告存放路径，为 - 时以 JSON 输出到标准输出，替代文本报告")
+}
+
+func run(cmd *cobra.Command, args []string) (err error) {
+	output, err := cmd.Flags().GetString("json")
+	if err != nil {
+		return
+	}
+	from, err := load(args[0])
+	if err != nil {
+		return
+	}
+	to, err := load(args[1])
+	if err != nil {
+		return
+	}
+	diff := exporter.DiffProtocol(from, to)
+	if output != "" {
+		var data []byte
+		data, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			err = fmt.Errorf("报告编码错误: %s", err)
+			return
+		}
+		if output == "-" {
+			fmt.Println(string(data))
+		} else if err = ioutil.WriteFile(output, data, 0644); err != nil {
+			err = fmt.Errorf("文件 '%s' 写入错误: %s", output, err)
+			return
+		}
+	}
+	if output != "-" {
+		report(diff)
+	}
+	if breaking := diff.BreakingChanges(); len(breaking) > 0 {
+		err = fmt.Errorf("存在 %d 项破坏性变更", len(breaking))
+	}
+	return
+}
+
+func load(path string) (protocol *exporter.ProtocolOutput, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("协议文件读取错误: %s", err)
+		return
+	}
+	protocol = new(exporter.ProtocolOutput)
+	err = json.Unmarshal(data, protocol)
+	if err != nil {
+		err = fmt.Errorf("协议文件 '%s' 解码错误: %s", path, err)
+		return
+	}
+	return
+}
+
+// 输出文本报告，破坏性变更在前
+func report(diff *exporter.ProtocolDiff) {
+	if len(diff.Changes) == 0 {
+		fmt.Println("协议无变更")
+		return
+	}
+	var breaking, compatible []*exporter.Change
+	for _, v := range diff.Changes {
+		if v.Breaking {
+			breaking = append(breaking, v)
+		} else {
+			compatible = append(compatible, v)
+		}
+	}
+	for _, group := range []struct {
+		title   string
+		mark    string
+		changes []*exporter.Change
+	}{
+		{"破坏性变更", "✗", breaking},
+		{"兼容变更", "✓", compatible},
+	} {
+		if len(group.changes) == 0 {
+			continue
+		}
+		fmt.Printf("%s (%d):\n", group.title, len(group.changes))
+		for _, v := range group.changes {
+			fmt.Printf("  %s [%s] %s %s", group.mark, v.Kind, v.Method, v.Route)
+			if v.Field != "" {
+				fmt.Printf(" %s", v.Field)
+			}
+			fmt.Printf(": %s", v.Message)
+			switch {
+			case v.Old != "" && v.New != "":
+				fmt.Printf("，%s => %s", v.Old, v.New)
+			case v.Old != "":
+				fmt.Printf("，%s", v.Old)
+			case v.New != "":
+				fmt.Printf("，%s", v.New)
+			}
+			fmt.Println()
+		}
+	}
+}
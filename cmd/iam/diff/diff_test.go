@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+func writeProtocol(t *testing.T, dir, name string, methods ...*exporter.Method) string {
+	t.Helper()
+	data, err := json.Marshal(&exporter.ProtocolOutput{Methods: methods})
+	require.NoError(t, err)
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	get := &exporter.Method{Name: "GetOrder", Path: "/orders/:id", Method: "GET"}
+	list := &exporter.Method{Name: "ListOrder", Path: "/orders", Method: "GET"}
+	old := writeProtocol(t, dir, "old.json", get)
+	added := writeProtocol(t, dir, "added.json", get, list)
+	removed := writeProtocol(t, dir, "removed.json", list)
+	report := filepath.Join(dir, "report.json")
+
+	Command.SetArgs([]string{old, added, "--json", report})
+	require.NoError(t, Command.Execute())
+	data, err := ioutil.ReadFile(report)
+	require.NoError(t, err)
+	diff := new(exporter.ProtocolDiff)
+	require.NoError(t, json.Unmarshal(data, diff))
+	assert.False(t, diff.Breaking)
+	require.Len(t, diff.Changes, 1)
+	assert.Equal(t, exporter.ChangeMethodAdded, diff.Changes[0].Kind)
+
+	// 存在破坏性变更时返回错误，命令以非零状态码退出
+	Command.SetArgs([]string{old, removed, "--json", report})
+	assert.EqualError(t, Command.Execute(), "存在 1 项破坏性变更")
+
+	Command.SetArgs([]string{old, filepath.Join(dir, "missing.json")})
+	assert.Error(t, Command.Execute())
+}
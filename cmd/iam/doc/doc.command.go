@@ -0,0 +1,79 @@
+package doc
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/utilslab/iam/exporter/docgen"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var Command = &cobra.Command{
+	Use:   "doc [packages]",
+	Short: "提取文档注释",
+	Long:  "解析 Go 源码中处理器方法、入参出参结构体及其字段的文档注释，生成在 init 中注册注释的 Go 文件，使导出器在运行时无需源码即可填充描述",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd, args)
+	},
+}
+
+func init() {
+	Command.Flags().StringP("output", "o", "docs.make.go", "指定生成文件路径")
+	Command.Flags().StringP("package", "p", "", "指定生成文件的包名称，未指定时取生成目录中已有文件的包名称")
+}
+
+func run(cmd *cobra.Command, args []string) (err error) {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return
+	}
+	pkg, err := cmd.Flags().GetString("package")
+	if err != nil {
+		return
+	}
+	if pkg == "" {
+		pkg, err = detectPackage(output)
+		if err != nil {
+			return
+		}
+	}
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	docs, err := docgen.ParseDocs(args...)
+	if err != nil {
+		err = fmt.Errorf("文档注释解析错误: %s", err)
+		return
+	}
+	data, err := docs.Source(pkg)
+	if err != nil {
+		err = fmt.Errorf("文件生成错误: %s", err)
+		return
+	}
+	err = ioutil.WriteFile(output, data, 0644)
+	if err != nil {
+		err = fmt.Errorf("文件 '%s' 写入错误: %s", output, err)
+		return
+	}
+	fmt.Printf("文件 '%s' 写入成功，共 %d 条注释\n", output, len(docs))
+	return
+}
+
+// 取生成目录中已有 Go 文件的包名称
+func detectPackage(output string) (pkg string, err error) {
+	dir := filepath.Dir(output)
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return info.Name() != filepath.Base(output) && !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.PackageClauseOnly)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	for name := range pkgs {
+		return name, nil
+	}
+	return "", fmt.Errorf("无法确定 '%s' 的包名称，请通过 --package 选项指定", dir)
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/utilslab/iam/cmd/iam/diff"
+	"github.com/utilslab/iam/cmd/iam/doc"
+	"github.com/utilslab/iam/cmd/iam/openapi"
 	"github.com/utilslab/iam/cmd/iam/sdk"
+	"os"
 )
 
 var rootCmd = &cobra.Command{
@@ -16,8 +20,11 @@ var rootCmd = &cobra.Command{
 func main() {
 	rootCmd.AddCommand(
 		sdk.Command,
+		openapi.Command,
+		diff.Command,
+		doc.Command,
 	)
 	if err := rootCmd.Execute(); err != nil {
-		return
+		os.Exit(1)
 	}
 }
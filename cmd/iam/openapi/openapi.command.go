@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/utilslab/iam/exporter"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+var Command = &cobra.Command{
+	Use:   "openapi",
+	Short: "生成 OpenAPI 文档",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd)
+	},
+}
+
+func init() {
+	Command.Flags().StringP("address", "a", "", "指定服务地址，如：http://localhost:8090")
+	Command.Flags().StringP("input", "i", "", "指定离线保存的接口描述协议文件，即 /protocol 的输出")
+	Command.Flags().StringP("version", "v", exporter.OpenAPI30, "指定 OpenAPI 版本，可选值：3.0、3.1")
+	Command.Flags().StringP("output", "o", "", "指定文档存放路径，以 .yaml、.yml 结尾时输出 YAML，未指定时以 JSON 输出到标准输出")
+}
+
+func run(cmd *cobra.Command) (err error) {
+	address, err := cmd.Flags().GetString("address")
+	if err != nil {
+		return
+	}
+	input, err := cmd.Flags().GetString("input")
+	if err != nil {
+		return
+	}
+	if address == "" && input == "" {
+		err = fmt.Errorf("请通过 --address 选项指定服务地址，或通过 --input 选项指定接口描述协议文件")
+		return
+	}
+	version, err := cmd.Flags().GetString("version")
+	if err != nil {
+		return
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return
+	}
+	var data []byte
+	if input != "" {
+		data, err = ioutil.ReadFile(input)
+		if err != nil {
+			err = fmt.Errorf("协议文件读取错误: %s", err)
+			return
+		}
+	} else {
+		data, err = request(address)
+		if err != nil {
+			return
+		}
+	}
+	protocol := new(exporter.ProtocolOutput)
+	err = json.Unmarshal(data, protocol)
+	if err != nil {
+		err = fmt.Errorf("协议解码错误: %s", err)
+		return
+	}
+	doc, err := exporter.NewOpenAPI(protocol).Document(version)
+	if err != nil {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(output))
+	if ext == ".yaml" || ext == ".yml" {
+		data, err = yaml.Marshal(doc)
+	} else {
+		data, err = json.MarshalIndent(doc, "", "  ")
+	}
+	if err != nil {
+		err = fmt.Errorf("文档编码错误: %s", err)
+		return
+	}
+	if output == "" {
+		fmt.Println(string(data))
+		return
+	}
+	err = ioutil.WriteFile(output, data, 0644)
+	if err != nil {
+		err = fmt.Errorf("文件 '%s' 写入错误: %s", output, err)
+		return
+	}
+	fmt.Printf("文件 '%s' 写入成功\n", output)
+	return
+}
+
+func request(address string) (data []byte, err error) {
+	res, err := http.Get(fmt.Sprintf("%s/protocol", strings.TrimSuffix(address, "/")))
+	if err != nil {
+		err = fmt.Errorf("协议下载请求错误: %s", err)
+		return
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	data, err = ioutil.ReadAll(res.Body)
+	if err != nil {
+		err = fmt.Errorf("协议下载读取错误: %s", err)
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("协议下载错误: status %d: %s", res.StatusCode, data)
+	}
+	return
+}
@@ -24,6 +24,7 @@ var Command = &cobra.Command{
 
 func init() {
 	Command.Flags().StringP("address", "a", "", "指定服务地址，如：http://localhost:8090")
+	Command.Flags().StringP("input", "i", "", "指定离线保存的接口描述协议文件，即 /protocol 的输出，指定后无需服务地址")
 	Command.Flags().StringP("target", "t", "", "指定 SDK 生成目标，可选值：go、angular、axios")
 	Command.Flags().StringP("output", "o", "", "指定 SDK 存放目录")
 	Command.Flags().StringP("package", "p", "", "指定 SDK 包名称")
@@ -35,8 +36,12 @@ func run(cmd *cobra.Command) (err error) {
 	if err != nil {
 		return
 	}
-	if address == "" {
-		err = fmt.Errorf("请通过 --address 选项指定服务地址, ，如：--address http://localhost:8090")
+	input, err := cmd.Flags().GetString("input")
+	if err != nil {
+		return
+	}
+	if address == "" && input == "" {
+		err = fmt.Errorf("请通过 --address 选项指定服务地址, ，如：--address http://localhost:8090，或通过 --input 选项指定接口描述协议文件")
 		return
 	}
 	target, err := cmd.Flags().GetString("target")
@@ -67,7 +72,12 @@ func run(cmd *cobra.Command) (err error) {
 	if err != nil {
 		return
 	}
-	files, err := request(address, target, pkg)
+	var files []*exporter.File
+	if input != "" {
+		files, err = load(input, target, pkg)
+	} else {
+		files, err = request(address, target, pkg)
+	}
 	if err != nil {
 		return
 	}
@@ -117,6 +127,27 @@ func request(address, lang, pkg string) (files []*exporter.File, err error) {
 	return
 }
 
+// 由离线保存的接口描述协议在本地生成 SDK 文件
+func load(input, lang, pkg string) (files []*exporter.File, err error) {
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		err = fmt.Errorf("协议文件读取错误: %s", err)
+		return
+	}
+	protocol := new(exporter.ProtocolOutput)
+	err = json.Unmarshal(data, protocol)
+	if err != nil {
+		err = fmt.Errorf("协议解码错误: %s", err)
+		return
+	}
+	files, err = exporter.NewProtocolSDK(protocol).Files(exporter.DefaultMakers(), lang, pkg)
+	if err != nil {
+		err = fmt.Errorf("SDK 生成错误: %s", err)
+		return
+	}
+	return
+}
+
 func askMakeOutputDir(target string, yes bool) (err error) {
 	if dirExist(target) {
 		return
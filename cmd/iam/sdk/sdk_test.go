@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = ioutil.Discard
+}
+
+type GetPriceOut struct {
+	Price decimal.Decimal `json:"price"`
+}
+
+type testRouter []*iam.Route
+
+func (p testRouter) Routes() []*iam.Route {
+	return p
+}
+
+func getPrice(ctx context.Context) (*GetPriceOut, error) {
+	return &GetPriceOut{}, nil
+}
+
+// 以 Export 导出协议文件，模拟离线保存的 /protocol 输出
+func exportProtocol(t *testing.T, dir string) string {
+	t.Helper()
+	api := iam.New()
+	api.AddRouter(testRouter{{Groups: []*iam.Group{{Name: "price", Actions: []*iam.Action{{Type: iam.Read, Handler: getPrice}}}}}})
+	api.SetExporter("", nil)
+	require.NoError(t, api.Export(dir, iam.ExportProtocol))
+	return filepath.Join(dir, "protocol.json")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	input := exportProtocol(t, dir)
+
+	files, err := load(input, "go", "shop")
+	require.NoError(t, err)
+	var content string
+	for _, v := range files {
+		content += v.Content
+	}
+	assert.Contains(t, content, "(out *GetPriceOut, err error)")
+	assert.Contains(t, content, "Price decimal.Decimal")
+
+	_, err = load(input, "java", "shop")
+	assert.EqualError(t, err, "SDK 生成错误: target 'java' maker not found")
+
+	_, err = load(filepath.Join(dir, "missing.json"), "go", "shop")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "协议文件读取错误")
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, ioutil.WriteFile(bad, []byte("{"), 0644))
+	_, err = load(bad, "go", "shop")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "协议解码错误")
+	}
+}
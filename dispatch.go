@@ -0,0 +1,335 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/utilslab/iam/binding"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Endpoint 已预处理的路由端点，供 Driver 注册
+type Endpoint struct {
+	Method  string
+	Path    string // 完整路径，路径参数形如 :shopId
+	Route   *Route
+	Group   *Group
+	Action  *Action
+	invoker Invoker
+}
+
+// Endpoints 返回全部路由端点，需在 Validate 通过后调用
+func (p *API) Endpoints() []*Endpoint {
+	return p.endpoints
+}
+
+func (p *API) buildEndpoints() {
+	p.endpoints = nil
+	for _, route := range p.routes {
+		for _, group := range route.Groups {
+			for _, action := range group.Actions {
+				endpoint := &Endpoint{
+					Method: action.method,
+					Path:   strings.Join([]string{route.Prefix, group.Prefix, action.path}, ""),
+					Route:  route,
+					Group:  group,
+					Action: action,
+				}
+				endpoint.invoker = p.buildInvoker(endpoint)
+				p.endpoints = append(p.endpoints, endpoint)
+			}
+		}
+	}
+}
+
+// Handle 处理一次请求：绑定入参、鉴权、经拦截链调用 Handler 并输出结果
+//
+// 出错时不输出响应，由 Driver 交给自身的错误包装器或 WriteError 处理
+func (p *API) Handle(ctx context.Context, w http.ResponseWriter, r *http.Request, endpoint *Endpoint, params map[string]string) (err error) {
+	action := endpoint.Action
+	handler := action.handler
+	defer func() {
+		var coded *CodedError
+		if errors.As(err, &coded) {
+			err = p.resolveCodedError(action, coded)
+		}
+	}()
+	// 调用 Handler 前按输出类型协商编码器，没有可用编码器时返回 406；Html、Text 等原样输出及无包裹的空输出不经编码器
+	var encoder Encoder
+	if !p.isRaw(handler.Type()) && (p.envelope != nil || handler.Type().NumOut() == 2) {
+		var ok bool
+		if encoder, ok = p.negotiate(r, outputType(handler.Type())); !ok {
+			err = &CodedError{Status: http.StatusNotAcceptable, Code: CodeNotAcceptable, Message: "no acceptable encoder for " + r.Header.Get("Accept")}
+			return
+		}
+	}
+	var in interface{}
+	if handler.Type().NumIn() == 2 {
+		// 鉴权资源时保留 JSON 请求体，用于判断资源标识是否由请求提供
+		var body *bytes.Buffer
+		if p.authorizer != nil && len(action.Resources) > 0 && r.Body != nil && binding.Default(r.Method, contentType(r)) == binding.JSON {
+			body = new(bytes.Buffer)
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, body), r.Body}
+		}
+		var v reflect.Value
+		v, err = bind(r, params, handler.Type().In(1))
+		if err != nil {
+			err = p.invalidParams(r, handler.Type().In(1), err)
+			return
+		}
+		err = p.authorize(ctx, action, v, requestPresence(r, params, body))
+		if err != nil {
+			return
+		}
+		in = v.Interface()
+	} else {
+		err = p.authorize(ctx, action, reflect.Value{}, nil)
+		if err != nil {
+			return
+		}
+	}
+	stream := handler.Type().NumOut() == 2 && isStream(handler.Type().Out(0))
+	if stream && isWebSocket(r) {
+		if err = p.checkWebSocketOrigin(r); err != nil {
+			return
+		}
+	}
+	if stream {
+		var cancel context.CancelFunc
+		ctx, cancel = streamContext(ctx, r)
+		defer cancel()
+	}
+	out, err := endpoint.invoker(ctx, in)
+	if err != nil {
+		return
+	}
+	if stream {
+		err = p.writeEvents(ctx, w, r, out)
+		return
+	}
+	switch v := out.(type) {
+	case Html:
+		writeData(w, http.StatusOK, "text/html; charset=utf-8", []byte(v))
+		return
+	case Text:
+		writeData(w, http.StatusOK, "text/plain; charset=utf-8", []byte(v))
+		return
+	case File:
+		err = writeFile(w, &v)
+		return
+	case *File:
+		if v == nil {
+			v = new(File)
+		}
+		err = writeFile(w, v)
+		return
+	}
+	if handler.Type().NumOut() == 2 && handler.Type().Out(0) == streamType {
+		reader, _ := out.(io.Reader)
+		err = writeStream(w, "application/octet-stream", reader)
+		return
+	}
+	if p.envelope == nil && out == nil && handler.Type().NumOut() == 1 {
+		writeData(w, http.StatusOK, "text/plain; charset=utf-8", nil)
+		return
+	}
+	err = p.encode(ctx, w, encoder, http.StatusOK, p.envelopeCode(""), "", out)
+	return
+}
+
+// 返回 Handler 的输出类型，仅返回 error 时为 nil
+func outputType(t reflect.Type) reflect.Type {
+	if t.NumOut() == 2 {
+		return t.Out(0)
+	}
+	return nil
+}
+
+// WriteError 按默认规则输出错误：CodedError 输出错误码，鉴权拒绝输出 403，其余输出 400
+//
+// 未设置响应包裹时，鉴权拒绝与其余错误输出纯文本
+func (p *API) WriteError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusBadRequest, "BadRequest"
+	switch e := err.(type) {
+	case *CodedError:
+		if p.envelope == nil {
+			if p.write(ctx, w, r, e.Status, "", "", e) != nil {
+				writeData(w, e.Status, "text/plain; charset=utf-8", []byte(e.Error()))
+			}
+			return
+		}
+		if p.write(ctx, w, r, e.Status, e.Code, e.Message, e.Details) != nil {
+			writeData(w, e.Status, "text/plain; charset=utf-8", []byte(e.Error()))
+		}
+		return
+	case *DeniedError:
+		status, code = http.StatusForbidden, "AccessDenied"
+	}
+	if p.envelope == nil || p.write(ctx, w, r, status, code, err.Error(), nil) != nil {
+		writeData(w, status, "text/plain; charset=utf-8", []byte(err.Error()))
+	}
+}
+
+func (p *API) envelopeCode(code string) string {
+	if p.envelope != nil && code == "" {
+		return p.envelope.Success
+	}
+	return code
+}
+
+// 按 Accept 协商编码器输出，没有支持 data 类型的编码器时输出 JSON
+func (p *API) write(ctx context.Context, w http.ResponseWriter, r *http.Request, status int, code, message string, data interface{}) error {
+	encoder, _ := p.negotiate(r, reflect.TypeOf(data))
+	return p.encode(ctx, w, encoder, status, code, message, data)
+}
+
+// 使用 encoder 输出，设置响应包裹时 protobuf 以外的格式均包裹输出
+func (p *API) encode(ctx context.Context, w http.ResponseWriter, encoder Encoder, status int, code, message string, data interface{}) error {
+	v := data
+	if _, ok := encoder.(ProtobufEncoder); !ok && p.envelope != nil {
+		v = p.envelope.wrap(ctx, code, message, data)
+	}
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, v); err != nil {
+		return err
+	}
+	writeData(w, status, encoder.ContentType(), buf.Bytes())
+	return nil
+}
+
+func writeData(w http.ResponseWriter, status int, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func realType(t reflect.Type) reflect.Type {
+	for {
+		if t.Kind() != reflect.Ptr {
+			return t
+		}
+		t = t.Elem()
+	}
+}
+
+func bind(r *http.Request, params map[string]string, t reflect.Type) (reflect.Value, error) {
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = realType(t)
+	}
+	in := reflect.New(t)
+	// 路径参数与请求元数据先行映射，由请求体绑定统一校验
+	if err := mapLocations(r, params, in.Interface()); err != nil {
+		return in, err
+	}
+	b := binding.Default(r.Method, contentType(r))
+	err := b.Bind(r, in.Interface())
+	if err != nil {
+		return in, err
+	}
+	// 显式声明位置的字段只取自对应位置：丢弃请求体或表单写入的值后重新映射，值有变化时重新校验
+	bound := binding.TaggedValues(in.Interface(), locationTags...)
+	binding.ResetTagged(in.Interface(), locationTags...)
+	if err = mapLocations(r, params, in.Interface()); err != nil {
+		return in, err
+	}
+	if binding.Validator != nil && !reflect.DeepEqual(bound, binding.TaggedValues(in.Interface(), locationTags...)) {
+		if err = binding.Validator.ValidateStruct(in.Interface()); err != nil {
+			return in, err
+		}
+	}
+	if ptr {
+		return in, nil
+	}
+	return in.Elem(), nil
+}
+
+// 声明参数位置的标签
+var locationTags = []string{"uri", "query", "header", "cookie"}
+
+// 映射路径参数及声明 query、header、cookie 标签的字段
+func mapLocations(r *http.Request, params map[string]string, obj interface{}) error {
+	if len(params) > 0 {
+		m := make(map[string][]string)
+		for k, v := range params {
+			m[k] = []string{v}
+		}
+		if err := binding.MapUri(obj, m); err != nil {
+			return err
+		}
+	}
+	if err := binding.MapQuery(obj, r.URL.Query()); err != nil {
+		return err
+	}
+	if err := binding.MapHeader(obj, r.Header); err != nil {
+		return err
+	}
+	return binding.MapCookie(obj, r.Cookies())
+}
+
+// 按绑定来源判断字段是否由请求提供：声明位置的字段取自路径参数、query、header 或 cookie，
+// 其余字段取自 JSON 请求体的顶层键，body 为 nil 时取自已解析的表单
+func requestPresence(r *http.Request, params map[string]string, body *bytes.Buffer) presence {
+	var keys map[string]json.RawMessage
+	if body != nil {
+		// 请求体已通过绑定，非对象时视为未提供任何字段
+		_ = json.Unmarshal(body.Bytes(), &keys)
+	}
+	return func(f reflect.StructField) bool {
+		if key := tagName(f, "uri"); key != "" {
+			_, ok := params[key]
+			return ok
+		}
+		if key := tagName(f, "query"); key != "" {
+			_, ok := r.URL.Query()[key]
+			return ok
+		}
+		if key := tagName(f, "header"); key != "" {
+			return len(r.Header.Values(key)) > 0
+		}
+		if key := tagName(f, "cookie"); key != "" {
+			_, err := r.Cookie(key)
+			return err == nil
+		}
+		if body != nil {
+			return jsonPresence(keys)(f)
+		}
+		key := tagName(f, "form")
+		if key == "" {
+			key = f.Name
+		}
+		if _, ok := r.Form[key]; ok {
+			return true
+		}
+		if r.MultipartForm != nil {
+			_, ok := r.MultipartForm.File[key]
+			return ok
+		}
+		return false
+	}
+}
+
+func tagName(f reflect.StructField, key string) string {
+	name := strings.Split(f.Tag.Get(key), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func contentType(r *http.Request) string {
+	v := r.Header.Get("Content-Type")
+	for i, c := range v {
+		if c == ' ' || c == ';' {
+			return v[:i]
+		}
+	}
+	return v
+}
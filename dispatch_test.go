@@ -0,0 +1,82 @@
+package iam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTenantIn struct {
+	Tenant string         `header:"X-Tenant" json:"tenant" form:"tenant" binding:"required"`
+	Sid    string         `cookie:"sid" json:"sid" form:"sid"`
+	Name   string         `json:"name" form:"name"`
+	Meta   testTenantMeta `json:"meta"`
+}
+
+type testTenantMeta struct {
+	Trace string `header:"X-Trace" json:"trace"`
+}
+
+type testTenantService struct {
+	in *testTenantIn
+}
+
+func (p *testTenantService) GetTenant(ctx context.Context, in *testTenantIn) error {
+	p.in = in
+	return nil
+}
+
+func (p *testTenantService) UpdateTenant(ctx context.Context, in *testTenantIn) error {
+	p.in = in
+	return nil
+}
+
+func TestBindLocations(t *testing.T) {
+	service := new(testTenantService)
+	api := newTestAPI("tenant",
+		&Action{Type: Read, Handler: service.GetTenant},
+		&Action{Type: Write, Handler: service.UpdateTenant},
+	)
+	for _, c := range []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header map[string]string
+		status int
+		want   testTenantIn
+	}{
+		{"header", http.MethodGet, "/GetTenant?name=a", "", map[string]string{"X-Tenant": "t1", "Cookie": "sid=s1"}, http.StatusOK,
+			testTenantIn{Tenant: "t1", Sid: "s1", Name: "a"}},
+		{"query ignored", http.MethodGet, "/GetTenant?tenant=t2&sid=s2&name=a", "", map[string]string{"X-Tenant": "t1"}, http.StatusOK,
+			testTenantIn{Tenant: "t1", Name: "a"}},
+		{"query only", http.MethodGet, "/GetTenant?tenant=t2", "", nil, http.StatusBadRequest, testTenantIn{}},
+		{"body ignored", http.MethodPost, "/UpdateTenant", `{"tenant":"t2","sid":"s2","name":"a","meta":{"trace":"x"}}`, map[string]string{"X-Tenant": "t1", "X-Trace": "r1"}, http.StatusOK,
+			testTenantIn{Tenant: "t1", Name: "a", Meta: testTenantMeta{Trace: "r1"}}},
+		{"body only", http.MethodPost, "/UpdateTenant", `{"tenant":"t2","name":"a"}`, nil, http.StatusBadRequest, testTenantIn{}},
+		{"form only", http.MethodPost, "/UpdateTenant", "tenant=t2&name=a", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusBadRequest, testTenantIn{}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			service.in = nil
+			r := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			if c.method == http.MethodPost {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := serve(t, api, r)
+			assert.Equal(t, c.status, w.Code, w.Body.String())
+			if c.status == http.StatusOK {
+				assert.Equal(t, &c.want, service.in)
+			} else {
+				assert.Nil(t, service.in)
+				assert.Contains(t, w.Body.String(), "tenant is required")
+			}
+		})
+	}
+}
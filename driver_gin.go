@@ -0,0 +1,112 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+var _ Driver = new(GinDriver)
+
+// NewGinDriver 基于 gin 的路由驱动，engine 为空时使用 gin.Default()
+func NewGinDriver(engine *gin.Engine) *GinDriver {
+	if engine == nil {
+		engine = gin.Default()
+	}
+	return &GinDriver{engine: engine}
+}
+
+// GinDriver 将 Action 注册为 gin 路由，支持 Route、Group 中间件以及 API 的 ContextWrapper、ErrorWrapper
+type GinDriver struct {
+	engine *gin.Engine
+}
+
+func (p *GinDriver) Engine() *gin.Engine {
+	return p.engine
+}
+
+func (p *GinDriver) Handler() http.Handler {
+	return p.engine
+}
+
+func (p *GinDriver) Start(addr string) error {
+	return p.engine.Run(addr)
+}
+
+// Register 递归注册路由树，处理中间件前缀逻辑，代理路由处理器为 Gin 控制器
+func (p *GinDriver) Register(api *API) (err error) {
+	registers := map[interface{}]Register{}
+	for _, endpoint := range api.Endpoints() {
+		route, group := endpoint.Route, endpoint.Group
+		routeRegister, ok := registers[route]
+		if !ok {
+			routeRegister = p.engine
+			if route.Prefix != "" || len(route.Middlewares) > 0 {
+				routeRegister = p.engine.Group(route.Prefix, route.Middlewares...)
+			}
+			registers[route] = routeRegister
+		}
+		groupRegister, ok := registers[group]
+		if !ok {
+			groupRegister = routeRegister
+			if group.Prefix != "" || len(group.Middlewares) > 0 {
+				groupRegister = routeRegister.Group(group.Prefix, group.Middlewares...)
+			}
+			registers[group] = groupRegister
+		}
+		path := endpoint.Action.path
+		switch endpoint.Method {
+		case http.MethodGet:
+			groupRegister.GET(path, p.proxyHandler(api, endpoint))
+		case http.MethodPost:
+			groupRegister.POST(path, p.proxyHandler(api, endpoint))
+		case http.MethodPut:
+			groupRegister.PUT(path, p.proxyHandler(api, endpoint))
+		case http.MethodPatch:
+			groupRegister.PATCH(path, p.proxyHandler(api, endpoint))
+		case http.MethodDelete:
+			groupRegister.DELETE(path, p.proxyHandler(api, endpoint))
+		case http.MethodHead:
+			groupRegister.HEAD(path, p.proxyHandler(api, endpoint))
+		case http.MethodOptions:
+			groupRegister.OPTIONS(path, p.proxyHandler(api, endpoint))
+		default:
+			err = fmt.Errorf("action '%s' method '%s' unsupported", endpoint.Action.name, endpoint.Method)
+			return
+		}
+	}
+	return
+}
+
+func (p *GinDriver) proxyHandler(api *API, endpoint *Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx context.Context
+		var err error
+		defer func() {
+			if err != nil {
+				if api.errorWrapper != nil {
+					api.errorWrapper(c, err)
+				} else {
+					api.WriteError(ctx, c.Writer, c.Request, err)
+				}
+			}
+		}()
+		if api.contextWrapper == nil {
+			ctx = context.Background()
+		} else {
+			ctx, err = api.contextWrapper(c)
+			if err != nil {
+				return
+			}
+		}
+		var params map[string]string
+		if len(c.Params) > 0 {
+			params = make(map[string]string, len(c.Params))
+			for _, v := range c.Params {
+				params[v.Key] = v.Value
+			}
+		}
+		err = api.Handle(ctx, c.Writer, c.Request, endpoint, params)
+	}
+}
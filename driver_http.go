@@ -0,0 +1,153 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var _ Driver = new(HTTPDriver)
+
+// NewHTTPDriver 基于 net/http 的路由驱动，可将生成的 Handler 挂载到任意 http 服务
+func NewHTTPDriver() *HTTPDriver {
+	return &HTTPDriver{
+		mux:    http.NewServeMux(),
+		routes: map[string]*httpRoutes{},
+	}
+}
+
+// HTTPRequestWrapper 根据请求构造服务上下文
+type HTTPRequestWrapper func(r *http.Request) (context.Context, error)
+
+// HTTPErrorWrapper 输出错误
+type HTTPErrorWrapper func(w http.ResponseWriter, r *http.Request, err error)
+
+// HTTPDriver 使用 http.ServeMux 注册路由，路径参数由驱动自行匹配
+//
+// gin 中间件无法在该驱动下执行，Route、Group 声明中间件时 Register 返回错误
+type HTTPDriver struct {
+	mux            *http.ServeMux
+	routes         map[string]*httpRoutes
+	contextWrapper HTTPRequestWrapper
+	errorWrapper   HTTPErrorWrapper
+}
+
+func (p *HTTPDriver) SetContextWrapper(contextWrapper HTTPRequestWrapper) {
+	p.contextWrapper = contextWrapper
+}
+
+func (p *HTTPDriver) SetErrorWrapper(errorWrapper HTTPErrorWrapper) {
+	p.errorWrapper = errorWrapper
+}
+
+func (p *HTTPDriver) Handler() http.Handler {
+	return p.mux
+}
+
+func (p *HTTPDriver) Start(addr string) error {
+	return http.ListenAndServe(addr, p.mux)
+}
+
+// Register 按路径中首个参数之前的静态前缀注册到 ServeMux，同一前缀下的端点由驱动按方法与路径段匹配
+func (p *HTTPDriver) Register(api *API) (err error) {
+	for _, endpoint := range api.Endpoints() {
+		if len(endpoint.Route.Middlewares) > 0 || len(endpoint.Group.Middlewares) > 0 {
+			err = fmt.Errorf("action '%s' gin middlewares unsupported by http driver", endpoint.Action.name)
+			return
+		}
+		segments := strings.Split(endpoint.Path, "/")
+		pattern := endpoint.Path
+		for i, v := range segments {
+			if strings.HasPrefix(v, ":") || strings.HasPrefix(v, "*") {
+				pattern = strings.Join(segments[:i], "/") + "/"
+				break
+			}
+		}
+		routes, ok := p.routes[pattern]
+		if !ok {
+			routes = &httpRoutes{driver: p, api: api}
+			p.routes[pattern] = routes
+			p.mux.Handle(pattern, routes)
+		}
+		routes.endpoints = append(routes.endpoints, endpoint)
+	}
+	return
+}
+
+func (p *HTTPDriver) serve(api *API, endpoint *Endpoint, params map[string]string, w http.ResponseWriter, r *http.Request) {
+	var ctx context.Context
+	var err error
+	defer func() {
+		if err != nil {
+			if p.errorWrapper != nil {
+				p.errorWrapper(w, r, err)
+			} else {
+				api.WriteError(ctx, w, r, err)
+			}
+		}
+	}()
+	if p.contextWrapper == nil {
+		ctx = r.Context()
+	} else {
+		ctx, err = p.contextWrapper(r)
+		if err != nil {
+			return
+		}
+	}
+	err = api.Handle(ctx, w, r, endpoint, params)
+}
+
+// 同一 ServeMux 模式下的端点
+type httpRoutes struct {
+	driver    *HTTPDriver
+	api       *API
+	endpoints []*Endpoint
+}
+
+func (p *httpRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	allowed := false
+	for _, endpoint := range p.endpoints {
+		params, ok := matchPath(endpoint.Path, r.URL.Path)
+		if !ok {
+			continue
+		}
+		if endpoint.Method != r.Method {
+			allowed = true
+			continue
+		}
+		p.driver.serve(p.api, endpoint, params, w, r)
+		return
+	}
+	if allowed {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// 按 gin 的规则匹配路径，:name 匹配单个路径段，*name 匹配剩余路径（含前导 /）
+func matchPath(pattern, path string) (params map[string]string, ok bool) {
+	ps, ss := strings.Split(pattern, "/"), strings.Split(path, "/")
+	params = map[string]string{}
+	for i, v := range ps {
+		if strings.HasPrefix(v, "*") {
+			params[v[1:]] = "/" + strings.Join(ss[i:], "/")
+			return params, true
+		}
+		if i >= len(ss) {
+			return nil, false
+		}
+		if strings.HasPrefix(v, ":") {
+			if ss[i] == "" {
+				return nil, false
+			}
+			params[v[1:]] = ss[i]
+			continue
+		}
+		if v != ss[i] {
+			return nil, false
+		}
+	}
+	return params, len(ps) == len(ss)
+}
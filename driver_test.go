@@ -0,0 +1,160 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPathIn struct {
+	ShopId int64  `uri:"shopId" json:"shopId"`
+	Path   string `uri:"path" json:"path"`
+}
+
+type testPathOut struct {
+	ShopId int64  `json:"shopId"`
+	Path   string `json:"path"`
+}
+
+type testPathService struct{}
+
+func (p testPathService) GetShop(ctx context.Context, in *testPathIn) (*testPathOut, error) {
+	if in.ShopId == 0 {
+		return nil, errors.New("shop not found")
+	}
+	return &testPathOut{ShopId: in.ShopId}, nil
+}
+
+func (p testPathService) GetFile(ctx context.Context, in *testPathIn) (*testPathOut, error) {
+	return &testPathOut{ShopId: in.ShopId, Path: in.Path}, nil
+}
+
+func newDriverAPI(driver Driver) *API {
+	service := testPathService{}
+	api := newTestAPI("shop",
+		&Action{Type: Read, Path: "/shops/:shopId", Handler: service.GetShop},
+		&Action{Type: Read, Path: "/shops/:shopId/files/*path", Handler: service.GetFile},
+	)
+	api.SetDriver(driver)
+	return api
+}
+
+func TestMatchPath(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		path    string
+		params  map[string]string
+		ok      bool
+	}{
+		{"/shops", "/shops", map[string]string{}, true},
+		{"/shops", "/shops/1", nil, false},
+		{"/shops/:shopId", "/shops/42", map[string]string{"shopId": "42"}, true},
+		{"/shops/:shopId", "/shops/", nil, false},
+		{"/shops/:shopId", "/shops", nil, false},
+		{"/shops/:shopId", "/shops/42/goods", nil, false},
+		{"/shops/:shopId/files/*path", "/shops/42/files/a/b.txt", map[string]string{"shopId": "42", "path": "/a/b.txt"}, true},
+		{"/shops/:shopId/files/*path", "/shops/42/files/", map[string]string{"shopId": "42", "path": "/"}, true},
+		{"/shops/:shopId/files/*path", "/shops/42/images/a", nil, false},
+	} {
+		params, ok := matchPath(c.pattern, c.path)
+		assert.Equal(t, c.ok, ok, "%s %s", c.pattern, c.path)
+		if c.ok {
+			assert.Equal(t, c.params, params, "%s %s", c.pattern, c.path)
+		}
+	}
+}
+
+func TestDrivers(t *testing.T) {
+	for name, driver := range map[string]func() Driver{
+		"gin":  func() Driver { return NewGinDriver(gin.New()) },
+		"http": func() Driver { return NewHTTPDriver() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := newDriverAPI(driver())
+			for _, c := range []struct {
+				method string
+				target string
+				status int
+				body   string
+			}{
+				{http.MethodGet, "/shops/42", http.StatusOK, `{"shopId":42,"path":""}`},
+				{http.MethodGet, "/shops/42/files/a/b.txt", http.StatusOK, `{"shopId":42,"path":"/a/b.txt"}`},
+				{http.MethodGet, "/shops/0", http.StatusBadRequest, "shop not found"},
+				{http.MethodGet, "/goods/42", http.StatusNotFound, ""},
+			} {
+				w := serve(t, api, httptest.NewRequest(c.method, c.target, nil))
+				assert.Equal(t, c.status, w.Code, "%s %s", c.method, c.target)
+				if c.status == http.StatusOK {
+					assert.JSONEq(t, c.body, w.Body.String())
+				} else if c.body != "" {
+					assert.Equal(t, c.body, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPDriver(t *testing.T) {
+	driver := NewHTTPDriver()
+	api := newDriverAPI(driver)
+	w := serve(t, api, httptest.NewRequest(http.MethodPost, "/shops/42", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	driver.SetContextWrapper(func(r *http.Request) (context.Context, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, errors.New("unauthorized")
+		}
+		return r.Context(), nil
+	})
+	w = serve(t, api, httptest.NewRequest(http.MethodGet, "/shops/42", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "unauthorized", w.Body.String())
+
+	driver.SetErrorWrapper(func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, strings.ToUpper(err.Error()), http.StatusUnauthorized)
+	})
+	w = serve(t, api, httptest.NewRequest(http.MethodGet, "/shops/42", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "UNAUTHORIZED\n", w.Body.String())
+}
+
+func TestHTTPDriverMiddlewares(t *testing.T) {
+	api := New()
+	api.SetDriver(NewHTTPDriver())
+	api.AddRouter(testRouter{{Groups: []*Group{{
+		Name:        "shop",
+		Middlewares: []gin.HandlerFunc{func(c *gin.Context) {}},
+		Actions:     []*Action{{Type: Read, Handler: new(testShopService).GetShop}},
+	}}}})
+	_, err := api.Handler()
+	assert.EqualError(t, err, "action 'GetShop' gin middlewares unsupported by http driver")
+}
+
+func TestGinDriverMiddlewares(t *testing.T) {
+	var calls []string
+	middleware := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			calls = append(calls, name)
+		}
+	}
+	driver := NewGinDriver(gin.New())
+	api := New()
+	api.SetDriver(driver)
+	api.AddRouter(testRouter{{Prefix: "/api", Middlewares: []gin.HandlerFunc{middleware("route")}, Groups: []*Group{{
+		Name:        "shop",
+		Prefix:      "/shop",
+		Middlewares: []gin.HandlerFunc{middleware("group")},
+		Actions:     []*Action{{Type: Read, Handler: new(testShopService).GetShop}},
+	}}}})
+	w := serve(t, api, httptest.NewRequest(http.MethodGet, "/api/shop/GetShop?shopId=42", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"route", "group"}, calls)
+	assert.Same(t, driver.Handler(), driver.Engine())
+}
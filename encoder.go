@@ -0,0 +1,216 @@
+package iam
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/utilslab/iam/binding"
+	"github.com/utilslab/iam/internal/json"
+	"gopkg.in/yaml.v2"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encoder 响应编码器
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+}
+
+// CodeNotAcceptable Accept 中没有可编码输出类型的编码器时返回的错误码，状态为 406
+const CodeNotAcceptable = "NotAcceptable"
+
+// TypedEncoder 可按输出类型判断能否编码的编码器，协商时跳过不支持输出类型的编码器
+type TypedEncoder interface {
+	Encoder
+	Supports(t reflect.Type) bool // t 为 nil 表示无输出
+}
+
+// 默认编码器，与 binding 支持的请求格式一致，msgpack 受 nomsgpack 构建标签控制
+var defaultEncoders = map[string]Encoder{
+	binding.MIMEJSON:     JSONEncoder{},
+	binding.MIMEXML:      XMLEncoder{},
+	binding.MIMEXML2:     XMLEncoder{},
+	binding.MIMEYAML:     YAMLEncoder{},
+	binding.MIMEPROTOBUF: ProtobufEncoder{},
+}
+
+// SetEncoder 为 Accept 中的 mime 类型注册响应编码器，覆盖同类型的默认编码器，mime 类型不区分大小写
+func (p *API) SetEncoder(mime string, encoder Encoder) {
+	if p.encoders == nil {
+		p.encoders = map[string]Encoder{}
+	}
+	p.encoders[strings.ToLower(mime)] = encoder
+}
+
+func (p *API) lookupEncoder(mime string) (Encoder, bool) {
+	mime = strings.ToLower(mime)
+	if encoder, ok := p.encoders[mime]; ok {
+		return encoder, true
+	}
+	encoder, ok := defaultEncoders[mime]
+	return encoder, ok
+}
+
+// 按 Accept 的权重选择支持输出类型 t 的编码器，均不可用时返回 JSON 及 false
+//
+// 权重相同的取值中 JSON 与通配优先，即 Accept 为空或最先匹配到通配时使用 JSON；
+// 最高权重的取值均无可用编码器且 Accept 含通配时同样使用 JSON，避免浏览器
+// 以 text/html,application/xml;q=0.9,*/*;q=0.8 访问时返回 XML
+func (p *API) negotiate(r *http.Request, t reflect.Type) (Encoder, bool) {
+	fallback, _ := p.lookupEncoder(binding.MIMEJSON)
+	accepts := parseAcceptWeights(r.Header.Get("Accept"))
+	if len(accepts) == 0 {
+		return fallback, true
+	}
+	wildcard := false
+	for _, v := range accepts {
+		if isWildcardAccept(v.value) {
+			wildcard = true
+		}
+	}
+	for i := 0; i < len(accepts); {
+		j := i
+		for j < len(accepts) && accepts[j].q == accepts[i].q {
+			j++
+		}
+		group := accepts[i:j]
+		for _, v := range group {
+			if isWildcardAccept(v.value) || strings.EqualFold(v.value, binding.MIMEJSON) {
+				return fallback, true
+			}
+		}
+		for _, v := range group {
+			if encoder, ok := p.lookupEncoder(v.value); ok && supportsType(encoder, t) {
+				return encoder, true
+			}
+		}
+		if wildcard {
+			return fallback, true
+		}
+		i = j
+	}
+	return fallback, false
+}
+
+func isWildcardAccept(v string) bool {
+	return v == "*/*" || strings.EqualFold(v, "application/*")
+}
+
+func supportsType(encoder Encoder, t reflect.Type) bool {
+	if v, ok := encoder.(TypedEncoder); ok {
+		return v.Supports(t)
+	}
+	return true
+}
+
+type acceptValue struct {
+	value string
+	q     float64
+}
+
+// 解析 Accept、Accept-Language 等请求头，按权重从高到低返回取值，忽略权重为 0 的取值
+func parseAccept(header string) (values []string) {
+	for _, v := range parseAcceptWeights(header) {
+		values = append(values, v.value)
+	}
+	return
+}
+
+func parseAcceptWeights(header string) (accepts []acceptValue) {
+	for _, v := range strings.Split(header, ",") {
+		parts := strings.Split(v, ";")
+		a := acceptValue{value: strings.TrimSpace(parts[0]), q: 1}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					a.q = q
+				}
+			}
+		}
+		if a.value != "" && a.q > 0 {
+			accepts = append(accepts, a)
+		}
+	}
+	sort.SliceStable(accepts, func(i, j int) bool {
+		return accepts[i].q > accepts[j].q
+	})
+	return
+}
+
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (JSONEncoder) Encode(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+type XMLEncoder struct{}
+
+func (XMLEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+// Supports encoding/xml 不支持编码 map
+func (XMLEncoder) Supports(t reflect.Type) bool {
+	return t == nil || realType(t).Kind() != reflect.Map
+}
+
+func (XMLEncoder) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+type YAMLEncoder struct{}
+
+func (YAMLEncoder) ContentType() string {
+	return "application/x-yaml; charset=utf-8"
+}
+
+func (YAMLEncoder) Encode(w io.Writer, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ProtobufEncoder 仅支持输出 proto.Message，不应用响应包裹
+type ProtobufEncoder struct{}
+
+func (ProtobufEncoder) ContentType() string {
+	return binding.MIMEPROTOBUF
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// Supports 仅支持 proto.Message 及接口类型的输出，接口类型的实际取值在编码时检查
+func (ProtobufEncoder) Supports(t reflect.Type) bool {
+	return t != nil && (t.Kind() == reflect.Interface || t.Implements(protoMessageType))
+}
+
+func (ProtobufEncoder) Encode(w io.Writer, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf encoder expect proto.Message, got %T", v)
+	}
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,25 @@
+//go:build !nomsgpack
+// +build !nomsgpack
+
+package iam
+
+import (
+	"github.com/ugorji/go/codec"
+	"github.com/utilslab/iam/binding"
+	"io"
+)
+
+func init() {
+	defaultEncoders[binding.MIMEMSGPACK] = MsgpackEncoder{}
+	defaultEncoders[binding.MIMEMSGPACK2] = MsgpackEncoder{}
+}
+
+type MsgpackEncoder struct{}
+
+func (MsgpackEncoder) ContentType() string {
+	return binding.MIMEMSGPACK2
+}
+
+func (MsgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	return codec.NewEncoder(w, new(codec.MsgpackHandle)).Encode(v)
+}
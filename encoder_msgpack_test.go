@@ -0,0 +1,29 @@
+//go:build !nomsgpack
+// +build !nomsgpack
+
+package iam
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"github.com/utilslab/iam/binding"
+)
+
+func TestMsgpackEncoder(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	for _, accept := range []string{binding.MIMEMSGPACK, binding.MIMEMSGPACK2} {
+		r := httptest.NewRequest(http.MethodGet, "/GetShop?shopId=42&color=red", nil)
+		r.Header.Set("Accept", accept)
+		w := serve(t, api, r)
+		require.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+		out := new(testShopOut)
+		require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), new(codec.MsgpackHandle)).Decode(out))
+		assert.Equal(t, &testShopOut{ShopId: 42, Color: "red"}, out)
+	}
+}
@@ -0,0 +1,202 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/binding"
+)
+
+type testMessageService struct {
+	calls int
+}
+
+func (p *testMessageService) GetMessage(ctx context.Context, in *testShopIn) (*wrappers.StringValue, error) {
+	p.calls++
+	return &wrappers.StringValue{Value: in.Color}, nil
+}
+
+func (p *testMessageService) GetMap(ctx context.Context, in *testShopIn) (map[string]string, error) {
+	p.calls++
+	return map[string]string{"color": in.Color}, nil
+}
+
+func TestParseAccept(t *testing.T) {
+	for _, c := range []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"application/json", []string{"application/json"}},
+		{"application/xml;q=0.5, application/x-yaml", []string{"application/x-yaml", "application/xml"}},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", []string{"text/html", "application/xml", "*/*"}},
+		{"application/xml;q=0, application/json;q=0.1", []string{"application/json"}},
+		{"zh-CN,zh;q=0.9,en;q=0.8", []string{"zh-CN", "zh", "en"}},
+	} {
+		assert.Equal(t, c.want, parseAccept(c.header), c.header)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	api := New()
+	messageType := reflect.TypeOf(new(wrappers.StringValue))
+	shopType := reflect.TypeOf(new(testShopOut))
+	mapType := reflect.TypeOf(map[string]string{})
+	for _, c := range []struct {
+		accept string
+		t      reflect.Type
+		want   Encoder
+		ok     bool
+	}{
+		{"", shopType, JSONEncoder{}, true},
+		{"application/xml", shopType, XMLEncoder{}, true},
+		{"application/x-yaml", shopType, YAMLEncoder{}, true},
+		{"application/x-protobuf", messageType, ProtobufEncoder{}, true},
+		{"application/x-protobuf, application/xml;q=0.5", shopType, XMLEncoder{}, true},
+		{"application/x-protobuf, */*;q=0.1", shopType, JSONEncoder{}, true},
+		{"application/x-protobuf", shopType, JSONEncoder{}, false},
+		{"application/x-protobuf", nil, JSONEncoder{}, false},
+		{"application/xml", mapType, JSONEncoder{}, false},
+		{"text/html", shopType, JSONEncoder{}, false},
+		// 浏览器的 Accept 未明确要求 XML，使用 JSON
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", shopType, JSONEncoder{}, true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9", shopType, XMLEncoder{}, true},
+		// 权重相同时 JSON 与通配优先
+		{"application/xml, */*", shopType, JSONEncoder{}, true},
+		{"application/xml, application/*", shopType, JSONEncoder{}, true},
+		{"application/xml, application/json", shopType, JSONEncoder{}, true},
+		{"application/xml, */*;q=0.1", shopType, XMLEncoder{}, true},
+		// 媒体类型不区分大小写
+		{"Application/XML", shopType, XMLEncoder{}, true},
+		{"application/X-YAML;q=0.5, text/html", shopType, YAMLEncoder{}, true},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", c.accept)
+		encoder, ok := api.negotiate(r, c.t)
+		assert.Equal(t, c.want, encoder, "%s %v", c.accept, c.t)
+		assert.Equal(t, c.ok, ok, "%s %v", c.accept, c.t)
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	for _, c := range []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"shopId":42,"color":"red"}`},
+		{"application/xml", "application/xml; charset=utf-8", `<testShopOut><ShopId>42</ShopId><Color>red</Color></testShopOut>`},
+		{"application/x-yaml", "application/x-yaml; charset=utf-8", "shopid: 42\ncolor: red\n"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/GetShop?shopId=42&color=red", nil)
+		r.Header.Set("Accept", c.accept)
+		w := serve(t, api, r)
+		require.Equal(t, http.StatusOK, w.Code, c.accept)
+		assert.Equal(t, c.contentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, c.body, w.Body.String())
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+	service := new(testMessageService)
+	api := newTestAPI("shop",
+		&Action{Type: Read, Handler: service.GetMessage},
+		&Action{Type: Read, Handler: service.GetMap},
+	)
+	for _, c := range []struct {
+		target string
+		accept string
+		status int
+		calls  int
+	}{
+		{"/GetMessage?color=red", binding.MIMEPROTOBUF, http.StatusOK, 1},
+		{"/GetMessage?color=red", "", http.StatusOK, 1},
+		{"/GetMap?color=red", binding.MIMEPROTOBUF, http.StatusNotAcceptable, 0},
+		{"/GetMap?color=red", binding.MIMEXML, http.StatusNotAcceptable, 0},
+		{"/GetMap?color=red", binding.MIMEXML + ", */*;q=0.1", http.StatusOK, 1},
+	} {
+		t.Run(c.target+" "+c.accept, func(t *testing.T) {
+			service.calls = 0
+			r := httptest.NewRequest(http.MethodGet, c.target, nil)
+			r.Header.Set("Accept", c.accept)
+			w := serve(t, api, r)
+			assert.Equal(t, c.status, w.Code, w.Body.String())
+			assert.Equal(t, c.calls, service.calls)
+			if c.status == http.StatusNotAcceptable {
+				assert.Contains(t, w.Body.String(), "no acceptable encoder for "+c.accept)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/GetMessage?color=red", nil)
+	r.Header.Set("Accept", binding.MIMEPROTOBUF)
+	w := serve(t, api, r)
+	out := new(wrappers.StringValue)
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), out))
+	assert.Equal(t, "red", out.Value)
+}
+
+type testTextEncoder struct{}
+
+func (testTextEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (testTextEncoder) Encode(w io.Writer, v interface{}) error {
+	_, err := fmt.Fprintf(w, "%+v", v)
+	return err
+}
+
+func TestSetEncoder(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetEncoder("text/plain", testTextEncoder{})
+	api.SetEncoder(binding.MIMEJSON, testTextEncoder{})
+	for _, accept := range []string{"text/plain", ""} {
+		r := httptest.NewRequest(http.MethodGet, "/GetShop?shopId=42&color=red", nil)
+		r.Header.Set("Accept", accept)
+		w := serve(t, api, r)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "&{ShopId:42 Color:red}", w.Body.String())
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	service := new(testShopService)
+	api := newTestAPI("shop",
+		&Action{Type: Read, Resources: []Resource{testShopResource}, Handler: service.GetShop},
+	)
+	api.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, req *AuthRequest) (bool, error) {
+		return req.Resource != "shop/7", nil
+	}))
+	api.SetEnvelope(&Envelope{Message: "msg", Trace: func(ctx context.Context) string { return "t1" }})
+	for _, c := range []struct {
+		target string
+		accept string
+		status int
+		body   string
+	}{
+		{"/GetShop?shopId=42&color=red", "", http.StatusOK, `{"code":"OK","data":{"shopId":42,"color":"red"},"traceId":"t1"}`},
+		{"/GetShop?shopId=7", "", http.StatusForbidden, `{"code":"AccessDenied","data":null,"msg":"access denied: action 'GetShop' on resource 'shop/7'","traceId":"t1"}`},
+		{"/GetShop?shopId=42", binding.MIMEXML, http.StatusOK, `<response><code>OK</code><data><ShopId>42</ShopId><Color></Color></data><traceId>t1</traceId></response>`},
+	} {
+		r := httptest.NewRequest(http.MethodGet, c.target, nil)
+		r.Header.Set("Accept", c.accept)
+		w := serve(t, api, r)
+		assert.Equal(t, c.status, w.Code, c.target)
+		if c.accept == binding.MIMEXML {
+			assert.Equal(t, c.body, w.Body.String())
+		} else {
+			assert.JSONEq(t, c.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package iam
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/utilslab/iam/exporter"
+	"reflect"
+)
+
+// Envelope 响应包裹结构，设置后成功与错误响应均输出为 {code, data, message, traceId}
+type Envelope struct {
+	Code    string                           // code 字段名，默认 code
+	Data    string                           // data 字段名，默认 data
+	Message string                           // message 字段名，默认 message
+	TraceId string                           // traceId 字段名，默认 traceId
+	Success string                           // 成功时 code 的取值，默认 OK
+	Trace   func(ctx context.Context) string // 获取链路 ID，为空时不输出 traceId
+	typ     reflect.Type
+}
+
+// SetEnvelope 设置响应包裹结构，Html、Text、File、Stream、事件流输出及 protobuf 编码不包裹
+func (p *API) SetEnvelope(envelope *Envelope) {
+	if envelope != nil {
+		n := *envelope
+		if n.Code == "" {
+			n.Code = "code"
+		}
+		if n.Data == "" {
+			n.Data = "data"
+		}
+		if n.Message == "" {
+			n.Message = "message"
+		}
+		if n.TraceId == "" {
+			n.TraceId = "traceId"
+		}
+		if n.Success == "" {
+			n.Success = "OK"
+		}
+		n.typ = n.makeType()
+		envelope = &n
+	}
+	p.envelope = envelope
+}
+
+// 按配置的字段名构造包裹类型，同时支持 json、xml、yaml、msgpack 编码
+func (p Envelope) makeType() reflect.Type {
+	field := func(name, key string, t reflect.Type, omitempty bool) reflect.StructField {
+		tag := key
+		if omitempty {
+			tag += ",omitempty"
+		}
+		return reflect.StructField{
+			Name: name,
+			Type: t,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s" xml:"%s" yaml:"%s" codec:"%s"`, tag, tag, tag, tag)),
+		}
+	}
+	stringType := reflect.TypeOf("")
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "XMLName", Type: reflect.TypeOf(xml.Name{}), Tag: `xml:"response" json:"-" yaml:"-" codec:"-"`},
+		field("Code", p.Code, stringType, false),
+		field("Data", p.Data, reflect.TypeOf((*interface{})(nil)).Elem(), false),
+		field("Message", p.Message, stringType, true),
+		field("TraceId", p.TraceId, stringType, true),
+	})
+}
+
+func (p Envelope) wrap(ctx context.Context, code, message string, data interface{}) interface{} {
+	v := reflect.New(p.typ).Elem()
+	v.Field(1).SetString(code)
+	if data != nil {
+		v.Field(2).Set(reflect.ValueOf(data))
+	}
+	v.Field(3).SetString(message)
+	if p.Trace != nil && ctx != nil {
+		v.Field(4).SetString(p.Trace(ctx))
+	}
+	return v.Interface()
+}
+
+func (p Envelope) exporter() *exporter.Envelope {
+	return &exporter.Envelope{
+		Code:    p.Code,
+		Data:    p.Data,
+		Message: p.Message,
+		TraceId: p.TraceId,
+		Success: p.Success,
+	}
+}
@@ -0,0 +1,65 @@
+package iam
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+// CodedError 带错误码的错误，代理处理器按 Action 声明的 Codes 映射 HTTP 状态，并按 Accept 编码返回
+type CodedError struct {
+	Status  int         `json:"-" xml:"-" yaml:"-"` // 未设置时取 Action 声明的状态
+	Code    string      `json:"code" xml:"code" yaml:"code"`
+	Message string      `json:"message,omitempty" xml:"message,omitempty" yaml:"message,omitempty"` // 未设置时取 Action 声明的提示信息
+	Details interface{} `json:"details,omitempty" xml:"details,omitempty" yaml:"details,omitempty"`
+}
+
+func NewCodedError(code, message string) *CodedError {
+	return &CodedError{Code: code, Message: message}
+}
+
+func (e *CodedError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// WithDetails 返回附带详情的错误副本
+func (e *CodedError) WithDetails(details interface{}) *CodedError {
+	n := *e
+	n.Details = details
+	return &n
+}
+
+// New 由声明的 Code 构造错误
+func (p Code) New() *CodedError {
+	return &CodedError{Status: p.Status, Code: p.Code, Message: p.Message}
+}
+
+// 按 Action 声明补全错误码的状态与提示信息，未声明的错误码在调试模式下输出警告
+func (p *API) resolveCodedError(action *Action, e *CodedError) *CodedError {
+	n := *e
+	declared := e.Code == CodeInvalidParams || e.Code == CodeNotAcceptable || e.Code == CodeOriginDenied
+	for _, v := range action.Codes {
+		if v.Code != e.Code {
+			continue
+		}
+		declared = true
+		if n.Status == 0 {
+			n.Status = v.Status
+		}
+		if n.Message == "" {
+			n.Message = v.Message
+		}
+		break
+	}
+	if !declared && gin.IsDebugging() {
+		log.Printf("[WARNING] action '%s' returned undeclared error code '%s'", action.name, e.Code)
+	}
+	if n.Status == 0 {
+		n.Status = http.StatusBadRequest
+	}
+	return &n
+}
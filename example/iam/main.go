@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/utilslab/iam"
 	"github.com/utilslab/iam/exporter"
+	"log"
 	
 	service "github.com/utilslab/iam/example/iam/service"
 )
@@ -37,5 +38,7 @@ func main() {
 		},
 	})
 	
-	api.Run(":8080")
+	if err := api.Run(":8080"); err != nil {
+		log.Fatal(err)
+	}
 }
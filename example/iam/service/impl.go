@@ -11,8 +11,7 @@ func (i Impl) GetShop(ctx context.Context, in AddShopIn) (out AddShopOut, err er
 	return
 }
 
-func (i Impl) ListGoods(ctx context.Context, in AddShopIn) (out AddShopOut, err error) {
-	out.ShopId = in.ShopId
+func (i Impl) ListGoods(ctx context.Context, in ListGoodsIn) (out AddShopOut, err error) {
 	out.CateId = in.CateId
 	return
 }
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utilslab/iam"
+)
+
+func TestRoutes(t *testing.T) {
+	api := iam.New()
+	api.AddRouter(NewShopServiceRouter(new(Impl)))
+	assert.NoError(t, api.Validate())
+}
@@ -6,7 +6,7 @@ import (
 
 type ShopService interface {
 	GetShop(ctx context.Context, in AddShopIn) (out AddShopOut, err error)
-	ListGoods(ctx context.Context, in AddShopIn) (out AddShopOut, err error)
+	ListGoods(ctx context.Context, in ListGoodsIn) (out AddShopOut, err error)
 }
 
 type AddShopIn struct {
@@ -14,6 +14,11 @@ type AddShopIn struct {
 	CateId int64
 }
 
+type ListGoodsIn struct {
+	CateId int64
+	GoodId int64 `json:"goodId" form:"goodId"`
+}
+
 type AddShopOut struct {
 	ShopId int64
 	CateId int64
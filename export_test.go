@@ -0,0 +1,192 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+type testPriceIn struct {
+	ShopId int64 `json:"shopId" binding:"required"`
+}
+
+type testPriceOut struct {
+	Price decimal.Decimal `json:"price"`
+}
+
+type testPriceService struct{}
+
+func (p testPriceService) GetPrice(ctx context.Context, in *testPriceIn) (*testPriceOut, error) {
+	return &testPriceOut{}, nil
+}
+
+func newExportAPI() *API {
+	api := newTestAPI("price", &Action{Type: Read, Handler: testPriceService{}.GetPrice})
+	api.SetVersion("1.0.0")
+	api.SetExporter("", nil)
+	return api
+}
+
+// 读取目录下的全部文件，键为文件名
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, v := range infos {
+		data, err := ioutil.ReadFile(filepath.Join(dir, v.Name()))
+		require.NoError(t, err)
+		files[v.Name()] = string(data)
+	}
+	return files
+}
+
+func TestExport(t *testing.T) {
+	var starts int
+	api := newExportAPI()
+	api.OnStart(func(ctx context.Context) error {
+		starts++
+		return nil
+	})
+	dir := filepath.Join(t.TempDir(), "sdk")
+	require.NoError(t, api.Export(dir, "go"))
+	files := readDir(t, dir)
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	assert.NotEmpty(t, names)
+	var content string
+	for _, v := range names {
+		content += files[v]
+	}
+	assert.Contains(t, content, "func (s SDK) GetPrice(ctx context.Context")
+	assert.Contains(t, content, "decimal.Decimal")
+	assert.Zero(t, starts)
+	assert.Len(t, api.RouteTable().Rows(), 1)
+
+	dir = t.TempDir()
+	require.NoError(t, api.Export(dir, ExportProtocol))
+	files = readDir(t, dir)
+	require.Contains(t, files, "protocol.json")
+	protocol := new(exporter.ProtocolOutput)
+	require.NoError(t, json.Unmarshal([]byte(files["protocol.json"]), protocol))
+	assert.Equal(t, "1.0.0", protocol.Version)
+	require.Len(t, protocol.Methods, 1)
+	assert.Equal(t, "GetPrice", protocol.Methods[0].Name)
+
+	assert.EqualError(t, api.Export(t.TempDir(), "java"), "target 'java' maker not found")
+	assert.EqualError(t, newTestAPI("price").Export(t.TempDir(), "go"), "exporter not set, call SetExporter first")
+}
+
+// 由离线保存的协议生成的 SDK 与进程内生成的一致，基础类型映射按协议的 basics 恢复
+func TestExportOffline(t *testing.T) {
+	api := newExportAPI()
+	dir := t.TempDir()
+	require.NoError(t, api.Export(dir, ExportProtocol))
+	protocol := new(exporter.ProtocolOutput)
+	require.NoError(t, json.Unmarshal([]byte(readDir(t, dir)["protocol.json"]), protocol))
+
+	for _, lang := range []string{"go", "axios", "angular", "umi"} {
+		t.Run(lang, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, api.Export(dir, lang))
+			want := readDir(t, dir)
+			files, err := exporter.NewProtocolSDK(protocol).Files(exporter.DefaultMakers(), lang, "")
+			require.NoError(t, err)
+			got := map[string]string{}
+			for _, v := range files {
+				got[v.Name] = v.Content
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// 生成的 Go SDK 按类型名声明结构体，往返测试的入参与出参需导出
+type RoundTripUploadIn struct {
+	Title  string                `json:"title"`
+	Avatar *multipart.FileHeader `form:"avatar"`
+}
+
+type RoundTripUploadOut struct {
+	Title  string `json:"title"`
+	Avatar string `json:"avatar"`
+}
+
+type testUploadService struct{}
+
+func (p testUploadService) Upload(ctx context.Context, in *RoundTripUploadIn) (*RoundTripUploadOut, error) {
+	out := &RoundTripUploadOut{Title: in.Title}
+	if in.Avatar != nil {
+		out.Avatar = in.Avatar.Filename
+	}
+	return out, nil
+}
+
+const testUploadMain = `package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"roundtrip/sdk"
+)
+
+func main() {
+	out, err := sdk.NewSDK(os.Args[1]).Upload(context.Background(), &sdk.RoundTripUploadIn{
+		Title:  "新店",
+		Avatar: &sdk.File{Name: "avatar.png", Reader: strings.NewReader("png")},
+	})
+	if err != nil {
+		panic(err)
+	}
+	json.NewEncoder(os.Stdout).Encode(out)
+}
+`
+
+// 生成的 Go SDK 以 multipart 表单调用真实服务，文件以外的字段同样按服务端的表单名绑定
+func TestExportMultipartRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the generated SDK")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	api := newTestAPI("shop", &Action{Type: Write, Handler: testUploadService{}.Upload})
+	api.SetExporter("", nil)
+	handler, err := api.Handler()
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, api.Export(filepath.Join(dir, "sdk"), "go"))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module roundtrip\n\ngo 1.16\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(testUploadMain), 0644))
+	cmd := exec.Command(goBin, "run", ".", server.URL)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	out := new(RoundTripUploadOut)
+	require.NoError(t, json.Unmarshal(output, out), string(output))
+	assert.Equal(t, &RoundTripUploadOut{Title: "新店", Avatar: "avatar.png"}, out, string(output))
+}
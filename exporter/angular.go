@@ -21,8 +21,8 @@ var TsTyper Typer = func(s string, isStruct, isArray bool) string {
 func (a AngularMaker) Lang() string {
 	return Ts
 }
-func (a AngularMaker) Make(pkg string, methods []*Method) (files []*File, err error) {
-	data := MakeRenderData(a.Lang(), methods, EmptyNamer, TsTyper)
+func (a AngularMaker) Make(pkg string, methods []*Method, models []*Field) (files []*File, err error) {
+	data := MakeRenderData(a.Lang(), methods, models, EmptyNamer, TsTyper)
 	for _, v := range data.Structs {
 		for _, vv := range v.Fields {
 			if vv.Param == "" {
@@ -30,9 +30,10 @@ func (a AngularMaker) Make(pkg string, methods []*Method) (files []*File, err er
 			}
 		}
 	}
+	makeTsRules(data)
 	serviceFile := new(File)
 	serviceFile.Name = "service.make.ts"
-	serviceFile.Content, err = Render(angularServiceTpl, data, EmptyFormatter)
+	serviceFile.Content, err = Render(angularServiceTpl+tsFormDataTpl+tsLocationTpl+tsEventTpl+tsErrorsTpl+tsValidateTpl(""), data, EmptyFormatter)
 	if err != nil {
 		return
 	}
@@ -40,8 +41,9 @@ func (a AngularMaker) Make(pkg string, methods []*Method) (files []*File, err er
 	return
 }
 
-const angularServiceTpl = `import {HttpClient, HttpHeaders, HttpParams} from '@angular/common/http';
-import {Observable} from 'rxjs';
+const angularServiceTpl = `import {HttpClient, HttpErrorResponse, HttpHeaders, HttpParams} from '@angular/common/http';
+import {Observable, throwError} from 'rxjs';
+import {catchError, map} from 'rxjs/operators';
 
 export class APIService {
 
@@ -53,15 +55,33 @@ export class APIService {
       this.host = host;
     }
 {% for method in Methods %}
-{% if method.Description %}    // {{ method.Description }}{% endif %}
-    {{ method.Name }}({% if method.InputType !='' %}params:{{ method.InputType }}, {% endif %}options?:HttpOptions):{% if method.OutputType !='' %}Observable<{{ method.OutputType }}>{% else %}Observable<null>{% endif %}{ {% if method.InputType !='' %}
+{% if method.Description %}    // {{ _comment(method.Description) }}{% endif %}{% if method.Stream %}
+    {{ method.Name }}({% if method.InputType !='' %}params:{{ method.InputType }}, {% endif %}init?:EventSourceInit):Observable<{{ method.EventType }}>{
+        return new Observable<{{ method.EventType }}>(subscriber => {
+            const source = subscribe<{{ method.EventType }}>(this.host+{% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, {% if method.InputType !='' %}params{% else %}null{% endif %}, {
+                next: v => subscriber.next(v),
+                error: e => subscriber.error(e),
+                complete: () => subscriber.complete(),
+            }, init);
+            return () => source.close();
+        });
+    }{% else %}
+    {{ method.Name }}({% if method.InputType !='' %}params:{{ method.InputType }}, {% endif %}options?:HttpOptions):{% if method.OutputType !='' %}Observable<{{ method.OutputType }}>{% else %}Observable<null>{% endif %}{ {% if method.InputType !='' or method.Binary %}
 	    if(!options){
            options = {};
-	    }
+	    }{% endif %}{% if method.Binary %}
+	    options.responseType = 'blob';{% endif %}{% if method.Locations %}
+	    const located = splitParams(params, {{ method.Locations|safe }});
+	    options.headers = Object.assign({}, options.headers, located.headers);
 		{% if  method.Method == 'GET' or method.Method == 'DELETE' %}  // @ts-ignore
-		  options.params = params;{% else %}  options.body = params;{% endif %}{% endif %}
-	    return this.client.request('{{ method.Method }}', this.host+'{{ method.Path }}', options)
-    }{% endfor %}
+		  options.params = {...located.rest, ...located.query};{% else %}  options.params = located.query;
+		  options.body = {% if method.Multipart %}toFormData(located.rest){% else %}located.rest{% endif %};{% endif %}{% elif method.InputType !='' %}
+		{% if  method.Method == 'GET' or method.Method == 'DELETE' %}  // @ts-ignore
+		  options.params = params;{% elif method.Multipart %}  options.body = toFormData(params);{% else %}  options.body = params;{% endif %}{% endif %}
+	    return this.client.request('{{ method.Method }}', this.host+{% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, options){% if method.Envelope %}
+            .pipe(map((res: any) => res && res['{{ method.Envelope }}'])){% endif %}
+            .pipe(catchError((e: any) => throwError(e instanceof HttpErrorResponse ? toAPIError(e.status, e.error) : e)));
+    }{% endif %}{% endfor %}
 }
 
 export interface HttpOptions {
@@ -79,8 +99,9 @@ export interface HttpOptions {
 }
 
 {% for struct in Structs %}
-export interface {{ struct.Name }} {
-{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{field.Label}} {{field.Description}}{% endif %}
+{% if struct.Description %}// {{ _comment(struct.Description) }}
+{% endif %}export interface {{ struct.Name }} {
+{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{ _comment(field.Label) }} {{ _comment(field.Description) }}{% endif %}
 {% endfor %}}
 {% endfor %}
 `
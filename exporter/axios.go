@@ -7,8 +7,8 @@ func (a AxiosMaker) Lang() string {
 	return Ts
 }
 
-func (a AxiosMaker) Make(pkg string, methods []*Method) (files []*File, err error) {
-	data := MakeRenderData(a.Lang(), methods, EmptyNamer, TsTyper)
+func (a AxiosMaker) Make(pkg string, methods []*Method, models []*Field) (files []*File, err error) {
+	data := MakeRenderData(a.Lang(), methods, models, EmptyNamer, TsTyper)
 	for _, v := range data.Structs {
 		for _, vv := range v.Fields {
 			if vv.Param == "" {
@@ -16,9 +16,10 @@ func (a AxiosMaker) Make(pkg string, methods []*Method) (files []*File, err erro
 			}
 		}
 	}
+	makeTsRules(data)
 	serviceFile := new(File)
 	serviceFile.Name = "service.make.ts"
-	serviceFile.Content, err = Render(axiosServiceTpl, data, EmptyFormatter)
+	serviceFile.Content, err = Render(axiosServiceTpl+tsFormDataTpl+tsLocationTpl+tsEventTpl+tsErrorsTpl+tsValidateTpl(""), data, EmptyFormatter)
 	if err != nil {
 		return
 	}
@@ -28,21 +29,37 @@ func (a AxiosMaker) Make(pkg string, methods []*Method) (files []*File, err erro
 
 const axiosServiceTpl = `import axios, {AxiosPromise, AxiosRequestConfig} from 'axios';
 {% for method in Methods %}
-{% if method.Description %}// {{ method.Description }}{% endif %}
+{% if method.Description %}// {{ _comment(method.Description) }}{% endif %}{% if method.Stream %}
+export function {{ method.Name }}({% if method.InputType !='' %}params: {{ method.InputType }}, {% endif %}handlers: EventHandlers<{{ method.EventType }}>, init?: EventSourceInit): EventSource {
+	return subscribe<{{ method.EventType }}>((axios.defaults.baseURL || '') + {% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, {% if method.InputType !='' %}params{% else %}null{% endif %}, handlers, init);
+}{% else %}
 export function {{ method.Name }}({% if method.InputType !='' %}params: {{ method.InputType }}, {% endif %}request?: AxiosRequestConfig): {% if method.OutputType !='' %}AxiosPromise<{{ method.OutputType }}>{% else %}AxiosPromise<null>{% endif %} {
 	if (!request) {
 		request = {}
 	}
-	request.method = '{{ method.Method }}';
-	request.url = '{{ method.Path }}';
-	{% if method.InputType !='' %}{% if  method.Method == 'GET' or method.Method == 'DELETE' %}request.params = params;
+	request.method = '{{ method.Method }}';{% if method.Binary %}
+	request.responseType = 'blob';{% endif %}
+	request.url = {% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %};
+	{% if method.Locations %}const located = splitParams(params, {{ method.Locations|safe }});
+	request.headers = {...(request.headers || {}), ...located.headers};
+	{% if  method.Method == 'GET' or method.Method == 'DELETE' %}request.params = {...located.rest, ...located.query};
+	{% elif method.Multipart %}request.params = located.query;
+	request.data = toFormData(located.rest);
+	{% else %}request.params = located.query;
+	request.data = located.rest;{% endif %}
+	{% elif method.InputType !='' %}{% if  method.Method == 'GET' or method.Method == 'DELETE' %}request.params = params;
+	{% elif method.Multipart %}request.data = toFormData(params);
 	{% else %}request.data = params;{% endif %}{% endif %}
-	return axios(request)
-}
+	return axios(request){% if method.Envelope %}.then(res => {
+		res.data = res.data && res.data['{{ method.Envelope }}'];
+		return res;
+	}){% endif %}.catch(e => Promise.reject(e && e.response ? toAPIError(e.response.status, e.response.data) : e));
+}{% endif %}
 {% endfor %}
 {% for struct in Structs %}
-export interface {{ struct.Name }} {
-{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{field.Label}} {{field.Description}}{% endif %}
+{% if struct.Description %}// {{ _comment(struct.Description) }}
+{% endif %}export interface {{ struct.Name }} {
+{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{ _comment(field.Label) }} {{ _comment(field.Description) }}{% endif %}
 {% endfor %}}
 {% endfor %}
 `
@@ -0,0 +1,314 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 协议变更类型
+const (
+	ChangeMethodAdded   = "method-added"
+	ChangeMethodRemoved = "method-removed"
+	ChangePathChanged   = "path-changed"
+	ChangeVerbChanged   = "verb-changed"
+	ChangeFormatChanged = "format-changed" // 请求或响应格式变更，如 multipart、二进制、事件流、响应包裹
+	ChangeFieldAdded    = "field-added"
+	ChangeFieldRemoved  = "field-removed"
+	ChangeFieldRetyped  = "field-retyped"
+	ChangeFieldMoved    = "field-moved" // 入参位置变更，如 header 名称
+	ChangeFieldRequired = "field-required"
+	ChangeFieldOptional = "field-optional"
+	ChangeEnumChanged   = "enum-changed"
+)
+
+// Change 两份协议间的一项变更
+type Change struct {
+	Kind     string `json:"kind"`
+	Breaking bool   `json:"breaking"`
+	Method   string `json:"method"`
+	Route    string `json:"route"`           // 请求方法与路径，如 GET /api/nodes/:id
+	Field    string `json:"field,omitempty"` // 字段参数路径，如 input.items[].sku
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ProtocolDiff 协议比较结果
+type ProtocolDiff struct {
+	OldVersion string            `json:"oldVersion,omitempty"`
+	NewVersion string            `json:"newVersion,omitempty"`
+	Breaking   bool              `json:"breaking"`
+	Changes    []*Change         `json:"changes"`
+	olds       map[string]*Field // 旧协议的结构体定义，键为 Ref
+	news       map[string]*Field
+	comparing  map[string]bool // 正在比较的引用，递归出现时不再展开
+}
+
+// BreakingChanges 返回全部破坏性变更
+func (p ProtocolDiff) BreakingChanges() (changes []*Change) {
+	for _, v := range p.Changes {
+		if v.Breaking {
+			changes = append(changes, v)
+		}
+	}
+	return
+}
+
+func (p *ProtocolDiff) add(change *Change) {
+	p.Changes = append(p.Changes, change)
+	if change.Breaking {
+		p.Breaking = true
+	}
+}
+
+// DiffProtocol 比较新旧两份接口描述协议，按对已有调用方的影响区分破坏性变更
+//
+// 方法按名称匹配，同名方法优先匹配请求方法与路径均相同者；字段按参数名匹配，引用的结构体按各自协议中的定义比较。
+// 入参新增必填字段、收窄枚举为破坏性变更，出参扩大枚举为破坏性变更，删除与变更类型对入参、出参均为破坏性变更
+func DiffProtocol(from, to *ProtocolOutput) *ProtocolDiff {
+	d := &ProtocolDiff{
+		OldVersion: from.Version,
+		NewVersion: to.Version,
+		Changes:    make([]*Change, 0),
+		olds:       structDefinitions(from.Structs),
+		news:       structDefinitions(to.Structs),
+		comparing:  map[string]bool{},
+	}
+	matched := map[*Method]bool{}
+	for _, n := range to.Methods {
+		o := matchMethod(from.Methods, n, matched)
+		if o == nil {
+			d.add(&Change{Kind: ChangeMethodAdded, Method: n.Name, Route: methodRoute(n), Message: "新增方法"})
+			continue
+		}
+		matched[o] = true
+		d.diffMethod(o, n)
+	}
+	for _, o := range from.Methods {
+		if !matched[o] {
+			d.add(&Change{Kind: ChangeMethodRemoved, Breaking: true, Method: o.Name, Route: methodRoute(o), Message: "方法已删除"})
+		}
+	}
+	return d
+}
+
+func matchMethod(methods []*Method, m *Method, matched map[*Method]bool) *Method {
+	for _, v := range methods {
+		if !matched[v] && v.Name == m.Name && v.Method == m.Method && v.Path == m.Path {
+			return v
+		}
+	}
+	for _, v := range methods {
+		if !matched[v] && v.Name == m.Name {
+			return v
+		}
+	}
+	return nil
+}
+
+func methodRoute(m *Method) string {
+	return fmt.Sprintf("%s %s", m.Method, m.Path)
+}
+
+func (p *ProtocolDiff) diffMethod(o, n *Method) {
+	change := func(kind string, from, to string, message string) {
+		p.add(&Change{Kind: kind, Breaking: true, Method: n.Name, Route: methodRoute(n), Old: from, New: to, Message: message})
+	}
+	if o.Path != n.Path {
+		change(ChangePathChanged, o.Path, n.Path, "请求路径变更")
+	}
+	if o.Method != n.Method {
+		change(ChangeVerbChanged, o.Method, n.Method, "请求方法变更")
+	}
+	if o.Multipart != n.Multipart {
+		change(ChangeFormatChanged, requestFormat(o), requestFormat(n), "请求格式变更")
+	}
+	if responseFormat(o) != responseFormat(n) {
+		change(ChangeFormatChanged, responseFormat(o), responseFormat(n), "响应格式变更")
+	}
+	p.diffField(n, o.Input, n.Input, "input", true)
+	p.diffField(n, o.Output, n.Output, "output", false)
+	p.diffField(n, o.Event, n.Event, "event", false)
+}
+
+func requestFormat(m *Method) string {
+	if m.Multipart {
+		return "multipart/form-data"
+	}
+	return "application/json"
+}
+
+func responseFormat(m *Method) string {
+	var format string
+	switch {
+	case m.Binary:
+		format = "binary"
+	case m.Event != nil:
+		format = "event-stream"
+	default:
+		format = "json"
+	}
+	if e := m.Envelope; e != nil {
+		format += fmt.Sprintf(" envelope(code=%s,data=%s,message=%s,success=%s)", e.Code, e.Data, e.Message, e.Success)
+	}
+	return format
+}
+
+// 比较字段，input 为 true 时按入参规则判定，调用方可不传的变更视为兼容
+func (p *ProtocolDiff) diffField(m *Method, o, n *Field, path string, input bool) {
+	change := func(kind string, breaking bool, from, to string, message string) {
+		p.add(&Change{Kind: kind, Breaking: breaking, Method: m.Name, Route: methodRoute(m), Field: path, Old: from, New: to, Message: message})
+	}
+	if o == nil && n == nil {
+		return
+	}
+	if o != nil && n != nil {
+		if ot, nt := fieldType(o), fieldType(n); ot != nt {
+			change(ChangeFieldRetyped, true, ot, nt, "字段类型变更")
+			return
+		}
+		if input && (o.In != n.In || o.Key != n.Key) {
+			change(ChangeFieldMoved, true, fieldLocation(o), fieldLocation(n), "参数位置变更")
+		}
+		if input && !o.Validator.required() && n.Validator.required() {
+			change(ChangeFieldRequired, true, "", "", "字段变更为必填")
+		}
+		if input && o.Validator.required() && !n.Validator.required() {
+			change(ChangeFieldOptional, false, "", "", "字段变更为选填")
+		}
+		oe, ne := o.Validator.enums(), n.Validator.enums()
+		if !equalEnums(oe, ne) {
+			// 入参的可选值收窄、出参的可选值扩大时调用方可能无法处理
+			breaking := !containsEnums(ne, oe)
+			if !input {
+				breaking = !containsEnums(oe, ne)
+			}
+			change(ChangeEnumChanged, breaking, strings.Join(oe, " "), strings.Join(ne, " "), "枚举值变更")
+		}
+		if o.Array {
+			if o.Elem != nil && n.Elem != nil {
+				p.diffField(m, o.Elem, n.Elem, path+"[]", input)
+			}
+			return
+		}
+		if isReference(o) || isReference(n) {
+			// 按定义比较引用的结构体，定义缺失时不展开成员
+			o, n = resolveReference(p.olds, o), resolveReference(p.news, n)
+			if isReference(o) || isReference(n) {
+				return
+			}
+		}
+		if n.Ref != "" {
+			// 递归出现的结构体已在外层比较
+			if p.comparing[n.Ref] {
+				return
+			}
+			p.comparing[n.Ref] = true
+			defer delete(p.comparing, n.Ref)
+		}
+	}
+	var of, nf []*Field
+	if o != nil {
+		of = o.Fields
+	}
+	if n != nil {
+		nf = n.Fields
+	}
+	olds := map[string]*Field{}
+	for _, v := range of {
+		olds[v.Param] = v
+	}
+	news := map[string]bool{}
+	for _, v := range nf {
+		news[v.Param] = true
+		sub := fmt.Sprintf("%s.%s", path, v.Param)
+		if old, ok := olds[v.Param]; ok {
+			p.diffField(m, old, v, sub, input)
+			continue
+		}
+		if input && v.Validator.required() {
+			p.add(&Change{Kind: ChangeFieldRequired, Breaking: true, Method: m.Name, Route: methodRoute(m), Field: sub, New: fieldType(v), Message: "新增必填字段"})
+			continue
+		}
+		p.add(&Change{Kind: ChangeFieldAdded, Method: m.Name, Route: methodRoute(m), Field: sub, New: fieldType(v), Message: "新增字段"})
+	}
+	for _, v := range of {
+		if !news[v.Param] {
+			p.add(&Change{Kind: ChangeFieldRemoved, Breaking: true, Method: m.Name, Route: methodRoute(m), Field: fmt.Sprintf("%s.%s", path, v.Param), Old: fieldType(v), Message: "字段已删除"})
+		}
+	}
+}
+
+func structDefinitions(structs []*Field) map[string]*Field {
+	definitions := map[string]*Field{}
+	for _, v := range structs {
+		if v.Ref != "" {
+			definitions[v.Ref] = v
+		}
+	}
+	return definitions
+}
+
+func resolveReference(definitions map[string]*Field, field *Field) *Field {
+	if !isReference(field) {
+		return field
+	}
+	if def, ok := definitions[field.Ref]; ok {
+		return def
+	}
+	return field
+}
+
+func isReference(field *Field) bool {
+	return field.Ref != "" && len(field.Fields) == 0
+}
+
+// 字段类型描述，数组以 [] 前缀表示元素类型
+func fieldType(field *Field) string {
+	if field.Array && field.Elem != nil {
+		return "[]" + fieldType(field.Elem)
+	}
+	return field.Type
+}
+
+func fieldLocation(field *Field) string {
+	if field.In == "" {
+		return "body"
+	}
+	return fmt.Sprintf("%s:%s", field.In, field.Key)
+}
+
+func (p *Validator) required() bool {
+	return p != nil && p.Required
+}
+
+func (p *Validator) enums() []string {
+	if p == nil {
+		return nil
+	}
+	return p.Enums
+}
+
+func equalEnums(a, b []string) bool {
+	return containsEnums(a, b) && containsEnums(b, a)
+}
+
+// 检查枚举 a 是否包含 b 的全部取值，未声明枚举时可取任意值
+func containsEnums(a, b []string) bool {
+	if len(a) == 0 {
+		return true
+	}
+	if len(b) == 0 {
+		return false
+	}
+	values := map[string]bool{}
+	for _, v := range a {
+		values[v] = true
+	}
+	for _, v := range b {
+		if !values[v] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDiffMethod(input, output *Field) *Method {
+	return &Method{Name: "CreateOrder", Path: "/orders", Method: "POST", Input: input, Output: output}
+}
+
+func testDiffInput(fields ...*Field) *Field {
+	return &Field{Name: "CreateOrderIn", Type: "*CreateOrderIn", Struct: true, Ref: "CreateOrderIn", Fields: fields}
+}
+
+func testDiff(o, n *Method) *ProtocolDiff {
+	return DiffProtocol(&ProtocolOutput{Version: "1.0.0", Methods: []*Method{o}}, &ProtocolOutput{Version: "1.1.0", Methods: []*Method{n}})
+}
+
+func TestDiffMethods(t *testing.T) {
+	from := &ProtocolOutput{Methods: []*Method{
+		{Name: "GetOrder", Path: "/orders/:id", Method: "GET"},
+		{Name: "DeleteOrder", Path: "/orders/:id", Method: "DELETE"},
+	}}
+	to := &ProtocolOutput{Methods: []*Method{
+		{Name: "GetOrder", Path: "/orders/:id", Method: "GET"},
+		{Name: "ListOrder", Path: "/orders", Method: "GET"},
+	}}
+	d := DiffProtocol(from, to)
+	assert.True(t, d.Breaking)
+	assert.Equal(t, []*Change{
+		{Kind: ChangeMethodAdded, Method: "ListOrder", Route: "GET /orders", Message: "新增方法"},
+		{Kind: ChangeMethodRemoved, Breaking: true, Method: "DeleteOrder", Route: "DELETE /orders/:id", Message: "方法已删除"},
+	}, d.Changes)
+	assert.Equal(t, []*Change{d.Changes[1]}, d.BreakingChanges())
+
+	d = DiffProtocol(from, from)
+	assert.False(t, d.Breaking)
+	assert.Empty(t, d.Changes)
+	assert.NotNil(t, d.Changes)
+}
+
+// 同名方法优先匹配请求方法与路径均相同者，其余按名称匹配
+func TestDiffMethodMatch(t *testing.T) {
+	from := &ProtocolOutput{Methods: []*Method{
+		{Name: "Get", Path: "/orders/:id", Method: "GET"},
+		{Name: "Get", Path: "/goods/:id", Method: "GET"},
+	}}
+	to := &ProtocolOutput{Methods: []*Method{
+		{Name: "Get", Path: "/goods/:id", Method: "GET"},
+		{Name: "Get", Path: "/v2/orders/:id", Method: "POST"},
+	}}
+	d := DiffProtocol(from, to)
+	assert.Equal(t, []*Change{
+		{Kind: ChangePathChanged, Breaking: true, Method: "Get", Route: "POST /v2/orders/:id", Old: "/orders/:id", New: "/v2/orders/:id", Message: "请求路径变更"},
+		{Kind: ChangeVerbChanged, Breaking: true, Method: "Get", Route: "POST /v2/orders/:id", Old: "GET", New: "POST", Message: "请求方法变更"},
+	}, d.Changes)
+}
+
+func TestDiffFormat(t *testing.T) {
+	o := &Method{Name: "Export", Path: "/export", Method: "POST"}
+	for _, c := range []struct {
+		method *Method
+		old    string
+		new    string
+	}{
+		{&Method{Name: "Export", Path: "/export", Method: "POST", Multipart: true}, "application/json", "multipart/form-data"},
+		{&Method{Name: "Export", Path: "/export", Method: "POST", Binary: true}, "json", "binary"},
+		{&Method{Name: "Export", Path: "/export", Method: "POST", Event: &Field{Type: "string"}}, "json", "event-stream"},
+		{&Method{Name: "Export", Path: "/export", Method: "POST", Envelope: &Envelope{Code: "code", Data: "data", Message: "msg", Success: "OK"}}, "json", "json envelope(code=code,data=data,message=msg,success=OK)"},
+	} {
+		d := testDiff(o, c.method)
+		require.NotEmpty(t, d.Changes, c.new)
+		change := d.Changes[0]
+		assert.Equal(t, ChangeFormatChanged, change.Kind, c.new)
+		assert.True(t, change.Breaking, c.new)
+		assert.Equal(t, c.old, change.Old, c.new)
+		assert.Equal(t, c.new, change.New, c.new)
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	o := testDiffMethod(testDiffInput(
+		&Field{Name: "Sku", Param: "sku", Type: "string"},
+		&Field{Name: "Count", Param: "count", Type: "int"},
+		&Field{Name: "Tenant", Param: "tenant", Type: "string", In: InHeader, Key: "X-Tenant"},
+		&Field{Name: "Remark", Param: "remark", Type: "string"},
+		&Field{Name: "Coupon", Param: "coupon", Type: "string", Validator: &Validator{Required: true}},
+	), &Field{Type: "*Order", Struct: true, Fields: []*Field{
+		{Name: "Id", Param: "id", Type: "int64"},
+		{Name: "Total", Param: "total", Type: "float64"},
+	}})
+	n := testDiffMethod(testDiffInput(
+		&Field{Name: "Sku", Param: "sku", Type: "string", Validator: &Validator{Required: true}},
+		&Field{Name: "Count", Param: "count", Type: "int64"},
+		&Field{Name: "Tenant", Param: "tenant", Type: "string", In: InHeader, Key: "X-Tenant-Id"},
+		&Field{Name: "Coupon", Param: "coupon", Type: "string"},
+		&Field{Name: "Channel", Param: "channel", Type: "string", Validator: &Validator{Required: true}},
+		&Field{Name: "Note", Param: "note", Type: "string"},
+	), &Field{Type: "*Order", Struct: true, Fields: []*Field{
+		{Name: "Id", Param: "id", Type: "int64"},
+		{Name: "CreatedAt", Param: "createdAt", Type: "time.Time"},
+	}})
+	d := testDiff(o, n)
+	assert.True(t, d.Breaking)
+	assert.Equal(t, "1.0.0", d.OldVersion)
+	assert.Equal(t, "1.1.0", d.NewVersion)
+	route := "POST /orders"
+	assert.Equal(t, []*Change{
+		{Kind: ChangeFieldRequired, Breaking: true, Method: "CreateOrder", Route: route, Field: "input.sku", Message: "字段变更为必填"},
+		{Kind: ChangeFieldRetyped, Breaking: true, Method: "CreateOrder", Route: route, Field: "input.count", Old: "int", New: "int64", Message: "字段类型变更"},
+		{Kind: ChangeFieldMoved, Breaking: true, Method: "CreateOrder", Route: route, Field: "input.tenant", Old: "header:X-Tenant", New: "header:X-Tenant-Id", Message: "参数位置变更"},
+		{Kind: ChangeFieldOptional, Method: "CreateOrder", Route: route, Field: "input.coupon", Message: "字段变更为选填"},
+		{Kind: ChangeFieldRequired, Breaking: true, Method: "CreateOrder", Route: route, Field: "input.channel", New: "string", Message: "新增必填字段"},
+		{Kind: ChangeFieldAdded, Method: "CreateOrder", Route: route, Field: "input.note", New: "string", Message: "新增字段"},
+		{Kind: ChangeFieldRemoved, Breaking: true, Method: "CreateOrder", Route: route, Field: "input.remark", Old: "string", Message: "字段已删除"},
+		{Kind: ChangeFieldAdded, Method: "CreateOrder", Route: route, Field: "output.createdAt", New: "time.Time", Message: "新增字段"},
+		{Kind: ChangeFieldRemoved, Breaking: true, Method: "CreateOrder", Route: route, Field: "output.total", Old: "float64", Message: "字段已删除"},
+	}, d.Changes)
+}
+
+// 出参不区分必填与参数位置
+func TestDiffOutputRequired(t *testing.T) {
+	o := testDiffMethod(nil, &Field{Type: "*Order", Struct: true, Fields: []*Field{
+		{Name: "Id", Param: "id", Type: "int64"},
+	}})
+	n := testDiffMethod(nil, &Field{Type: "*Order", Struct: true, Fields: []*Field{
+		{Name: "Id", Param: "id", Type: "int64", Validator: &Validator{Required: true}},
+		{Name: "No", Param: "no", Type: "string", Validator: &Validator{Required: true}},
+	}})
+	d := testDiff(o, n)
+	assert.False(t, d.Breaking)
+	require.Len(t, d.Changes, 1)
+	assert.Equal(t, ChangeFieldAdded, d.Changes[0].Kind)
+	assert.Equal(t, "output.no", d.Changes[0].Field)
+}
+
+func TestDiffEnums(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		old      []string
+		new      []string
+		input    bool
+		breaking bool
+	}{
+		{"input narrowed", []string{"red", "blue"}, []string{"red"}, true, true},
+		{"input widened", []string{"red"}, []string{"red", "blue"}, true, false},
+		{"input restricted", nil, []string{"red"}, true, true},
+		{"input unrestricted", []string{"red"}, nil, true, false},
+		{"output narrowed", []string{"red", "blue"}, []string{"red"}, false, false},
+		{"output widened", []string{"red"}, []string{"red", "blue"}, false, true},
+		{"output restricted", nil, []string{"red"}, false, false},
+		{"output unrestricted", []string{"red"}, nil, false, true},
+	} {
+		field := func(enums []string) *Field {
+			f := testDiffInput(&Field{Name: "Color", Param: "color", Type: "string", Validator: &Validator{Enums: enums}})
+			if c.input {
+				return f
+			}
+			return &Field{Type: "*Good", Struct: true, Fields: f.Fields}
+		}
+		var o, n *Method
+		if c.input {
+			o, n = testDiffMethod(field(c.old), nil), testDiffMethod(field(c.new), nil)
+		} else {
+			o, n = testDiffMethod(nil, field(c.old)), testDiffMethod(nil, field(c.new))
+		}
+		d := testDiff(o, n)
+		require.Len(t, d.Changes, 1, c.name)
+		assert.Equal(t, ChangeEnumChanged, d.Changes[0].Kind, c.name)
+		assert.Equal(t, c.breaking, d.Changes[0].Breaking, c.name)
+		assert.Equal(t, c.breaking, d.Breaking, c.name)
+	}
+
+	// 仅顺序不同不视为变更
+	o := testDiffMethod(testDiffInput(&Field{Name: "Color", Param: "color", Type: "string", Validator: &Validator{Enums: []string{"red", "blue"}}}), nil)
+	n := testDiffMethod(testDiffInput(&Field{Name: "Color", Param: "color", Type: "string", Validator: &Validator{Enums: []string{"blue", "red"}}}), nil)
+	assert.Empty(t, testDiff(o, n).Changes)
+}
+
+func TestDiffArray(t *testing.T) {
+	item := func(fields ...*Field) *Field {
+		return testDiffInput(&Field{Name: "Items", Param: "items", Array: true, Elem: &Field{Type: "*Item", Struct: true, Fields: fields}})
+	}
+	o := testDiffMethod(item(&Field{Name: "Sku", Param: "sku", Type: "string"}), nil)
+	n := testDiffMethod(item(&Field{Name: "Sku", Param: "sku", Type: "int64"}), nil)
+	d := testDiff(o, n)
+	require.Len(t, d.Changes, 1)
+	assert.Equal(t, ChangeFieldRetyped, d.Changes[0].Kind)
+	assert.Equal(t, "input.items[].sku", d.Changes[0].Field)
+
+	// 元素类型变更时整体视为数组类型变更
+	n = testDiffMethod(testDiffInput(&Field{Name: "Items", Param: "items", Array: true, Elem: &Field{Type: "string"}}), nil)
+	d = testDiff(o, n)
+	require.Len(t, d.Changes, 1)
+	assert.Equal(t, &Change{Kind: ChangeFieldRetyped, Breaking: true, Method: "CreateOrder", Route: "POST /orders", Field: "input.items", Old: "[]*Item", New: "[]string", Message: "字段类型变更"}, d.Changes[0])
+}
+
+// 递归引用不展开，仅比较外层结构体
+func TestDiffRecursive(t *testing.T) {
+	cate := func(fields ...*Field) *Field {
+		children := &Field{Name: "Children", Param: "children", Array: true, Elem: &Field{Type: "*Cate", Struct: true, Nested: true, Ref: "Cate"}}
+		return &Field{Name: "Cate", Type: "*Cate", Struct: true, Ref: "Cate", Fields: append(fields, children)}
+	}
+	o := testDiffMethod(nil, cate(&Field{Name: "Id", Param: "id", Type: "int64"}))
+	n := testDiffMethod(nil, cate(&Field{Name: "Id", Param: "id", Type: "int64"}, &Field{Name: "Name", Param: "name", Type: "string"}))
+	d := testDiff(o, n)
+	require.Len(t, d.Changes, 1)
+	assert.Equal(t, "output.name", d.Changes[0].Field)
+	assert.False(t, d.Breaking)
+}
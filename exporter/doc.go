@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Docs 由源码文档注释提取的描述
+//
+// 键为 包路径.类型名、包路径.类型名.字段名、包路径.类型名.方法名 或 包路径.函数名，main 包同样使用其导入路径
+type Docs map[string]string
+
+var (
+	docs     = Docs{}
+	docsLock sync.RWMutex
+	// 运行时 main 包的包路径为 main，查找注释时替换为构建信息中的导入路径
+	mainPath = func() string {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Path != "" {
+			return info.Path
+		}
+		return "main"
+	}()
+)
+
+// RegisterDocs 注册文档注释，导出器据此填充未声明的方法描述与字段描述，通常由 iam doc 生成的文件在 init 中调用
+func RegisterDocs(items Docs) {
+	docsLock.Lock()
+	defer docsLock.Unlock()
+	for k, v := range items {
+		docs[k] = v
+	}
+}
+
+func lookupDoc(pkgPath, name string) string {
+	if pkgPath == "main" {
+		pkgPath = mainPath
+	}
+	docsLock.RLock()
+	defer docsLock.RUnlock()
+	return docs[fmt.Sprintf("%s.%s", pkgPath, name)]
+}
+
+// FuncDoc 返回函数或方法的文档注释，未注册时返回空
+func FuncDoc(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+	// 方法值形如 pkg.(*Service).Create-fm，包路径止于最后一个 / 之后的首个 .
+	name := strings.NewReplacer("(*", "", ")", "").Replace(strings.TrimSuffix(f.Name(), "-fm"))
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	dot += slash + 1
+	return lookupDoc(name[:dot], name[dot+1:])
+}
+
+// 类型的文档注释
+func typeDoc(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return ""
+	}
+	return lookupDoc(t.PkgPath(), t.Name())
+}
+
+// 结构体字段的文档注释
+func fieldDoc(t reflect.Type, name string) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return ""
+	}
+	return lookupDoc(t.PkgPath(), fmt.Sprintf("%s.%s", t.Name(), name))
+}
+
+// Source 生成在 init 中注册文档注释的 Go 源码，使运行时无需源码即可获取描述
+func (p Docs) Source(pkg string) ([]byte, error) {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	b.WriteString("// Code generated by iam doc. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\nimport \"github.com/utilslab/iam/exporter\"\n\n", pkg)
+	b.WriteString("func init() {\n\texporter.RegisterDocs(exporter.Docs{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\t\t%s: %s,\n", strconv.Quote(k), strconv.Quote(p[k]))
+	}
+	b.WriteString("\t})\n}\n")
+	return format.Source(b.Bytes())
+}
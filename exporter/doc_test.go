@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDocGood struct {
+	Id int64
+}
+
+type testDocService struct{}
+
+func (p *testDocService) Create() {}
+
+func TestLookupDoc(t *testing.T) {
+	pkg := reflect.TypeOf(testDocGood{}).PkgPath()
+	RegisterDocs(Docs{
+		pkg + ".testDocGood":           "商品",
+		pkg + ".testDocGood.Id":        "商品编号",
+		pkg + ".testDocService.Create": "创建商品",
+		"example.com/server.Handler":   "处理请求",
+	})
+	assert.Equal(t, "商品", typeDoc(reflect.TypeOf(testDocGood{})))
+	assert.Equal(t, "商品编号", fieldDoc(reflect.TypeOf(testDocGood{}), "Id"))
+	assert.Equal(t, "创建商品", FuncDoc(new(testDocService).Create))
+	assert.Empty(t, FuncDoc(TestLookupDoc))
+	assert.Empty(t, FuncDoc("Create"))
+
+	// main 包按构建信息中的导入路径查找
+	origin := mainPath
+	defer func() {
+		mainPath = origin
+	}()
+	mainPath = "example.com/server"
+	assert.Equal(t, "处理请求", lookupDoc("main", "Handler"))
+}
+
+// 描述以目标语言的注释输出，不做 HTML 转义且合并为一行
+func TestRenderDescription(t *testing.T) {
+	methods := []*Method{{
+		Name:        "CreateGood",
+		Description: "创建 <Good> & \"标记\"\n第二行",
+		Path:        "/goods",
+		Method:      "POST",
+		Output: &Field{Name: "Good", Type: "Good", Struct: true, StructDescription: "a <b> & c", Fields: []*Field{
+			{Name: "Name", Param: "name", Type: "string", Label: "名称", Description: "x < y\ny > z"},
+		}},
+	}}
+	for _, lang := range []string{"go", "axios", "angular", "umi"} {
+		content := testSDKContent(t, lang, methods)
+		assert.Contains(t, content, `创建 <Good> & "标记" 第二行`, lang)
+		assert.Contains(t, content, "a <b> & c", lang)
+		assert.Contains(t, content, "名称 x < y y > z", lang)
+		assert.False(t, strings.Contains(content, "&lt;") || strings.Contains(content, "&amp;") || strings.Contains(content, "&#34;"), lang)
+	}
+}
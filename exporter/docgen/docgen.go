@@ -0,0 +1,176 @@
+package docgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/utilslab/iam/exporter"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// 由 go list -json 输出的包信息
+type listPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	Error      *struct {
+		Err string
+	}
+}
+
+// ParseDocs 加载 Go 包，提取类型、字段、方法与函数的文档注释，需在模块内执行
+//
+// patterns 与 go build 的包参数一致，如 ./...，由 go list 按构建约束选取文件并遵循 go.mod 中的 replace；
+// 字段取文档注释，缺失时取行尾注释；注释以名称开头时去除名称，仅保留第一段并合并为一行
+func ParseDocs(patterns ...string) (items exporter.Docs, err error) {
+	pkgs, err := listPackages(patterns...)
+	if err != nil {
+		return
+	}
+	items = exporter.Docs{}
+	for _, pkg := range pkgs {
+		if pkg.Error != nil {
+			return nil, fmt.Errorf("load package '%s' error: %s", pkg.ImportPath, strings.TrimSpace(pkg.Error.Err))
+		}
+		fset := token.NewFileSet()
+		var files []*ast.File
+		for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+			var file *ast.File
+			file, err = parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.ParseComments)
+			if err != nil {
+				return nil, fmt.Errorf("parse package '%s' error: %s", pkg.ImportPath, err)
+			}
+			files = append(files, file)
+		}
+		var p *doc.Package
+		p, err = doc.NewFromFiles(fset, files, pkg.ImportPath, doc.AllDecls|doc.PreserveAST)
+		if err != nil {
+			return nil, fmt.Errorf("parse package '%s' error: %s", pkg.ImportPath, err)
+		}
+		parseDocs(p, items)
+	}
+	return
+}
+
+func listPackages(patterns ...string) (pkgs []*listPackage, err error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-e", "-json"}, patterns...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list error: %s", strings.TrimSpace(stderr.String()))
+	}
+	decoder := json.NewDecoder(&stdout)
+	for {
+		pkg := new(listPackage)
+		if err = decoder.Decode(pkg); err == io.EOF {
+			return pkgs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("decode go list output error: %s", err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+}
+
+// main 包同样以导入路径为键，运行时查找时由 main 转换
+func parseDocs(p *doc.Package, items exporter.Docs) {
+	add := func(key, name, text string) {
+		if text = docText(name, text); text != "" {
+			items[key] = text
+		}
+	}
+	funcs := p.Funcs
+	for _, t := range p.Types {
+		add(fmt.Sprintf("%s.%s", p.ImportPath, t.Name), t.Name, t.Doc)
+		for _, m := range t.Methods {
+			add(fmt.Sprintf("%s.%s.%s", p.ImportPath, t.Name, m.Name), m.Name, m.Doc)
+		}
+		funcs = append(funcs, t.Funcs...)
+		for _, spec := range t.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != t.Name {
+				continue
+			}
+			// 结构体字段及接口方法，以接口方法值作为 Handler 时取接口方法的注释
+			var list *ast.FieldList
+			switch v := ts.Type.(type) {
+			case *ast.StructType:
+				list = v.Fields
+			case *ast.InterfaceType:
+				list = v.Methods
+			default:
+				continue
+			}
+			for _, f := range list.List {
+				text := f.Doc.Text()
+				if text == "" {
+					text = f.Comment.Text()
+				}
+				for _, name := range fieldNames(f) {
+					add(fmt.Sprintf("%s.%s.%s", p.ImportPath, t.Name, name), name, text)
+				}
+			}
+		}
+	}
+	for _, f := range funcs {
+		add(fmt.Sprintf("%s.%s", p.ImportPath, f.Name), f.Name, f.Doc)
+	}
+}
+
+// 字段名称，嵌入字段以类型名为字段名
+func fieldNames(field *ast.Field) (names []string) {
+	for _, v := range field.Names {
+		names = append(names, v.Name)
+	}
+	if len(names) > 0 {
+		return
+	}
+	t := field.Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch v := t.(type) {
+	case *ast.Ident:
+		names = append(names, v.Name)
+	case *ast.SelectorExpr:
+		names = append(names, v.Sel.Name)
+	}
+	return
+}
+
+func docText(name, text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// 中文换行处不补空格
+		if b.Len() > 0 && !(isWide(lastRune(b.String())) || isWide([]rune(line)[0])) {
+			b.WriteString(" ")
+		}
+		b.WriteString(line)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(b.String(), name+" "))
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func isWide(r rune) bool {
+	return r >= 0x2E80
+}
@@ -0,0 +1,36 @@
+package docgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+func TestParseDocs(t *testing.T) {
+	items, err := ParseDocs("./testdata/docs/...")
+	require.NoError(t, err)
+	shop := "github.com/utilslab/iam/exporter/docgen/testdata/docs/shop"
+	assert.Equal(t, exporter.Docs{
+		shop + ".Good":           "商品",
+		shop + ".Good.Id":        "商品编号",
+		shop + ".Good.Name":      "商品名称",
+		shop + ".Meta":           "附加信息",
+		shop + ".Meta.CreatedAt": "创建时间",
+		shop + ".Service":        "商品服务",
+		shop + ".Service.Create": "creates a good and returns its id.",
+		shop + ".Reader":         "读取商品",
+		shop + ".Reader.Get":     "获取商品",
+		"github.com/utilslab/iam/exporter/docgen/testdata/docs/server.Handler": "处理请求",
+	}, items)
+
+	_, err = ParseDocs("./testdata/missing")
+	assert.Error(t, err)
+}
+
+func TestDocText(t *testing.T) {
+	assert.Equal(t, "creates a good", docText("Create", "Create creates a good\n"))
+	assert.Equal(t, "创建商品", docText("Create", "Create 创建\n商品\n\n第二段"))
+	assert.Equal(t, "first line second line", docText("", "first line\nsecond line"))
+}
@@ -0,0 +1,6 @@
+package main
+
+// Handler 处理请求
+func Handler() {}
+
+func main() {}
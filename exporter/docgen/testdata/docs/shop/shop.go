@@ -0,0 +1,31 @@
+package shop
+
+// Good 商品
+//
+// 第二段不提取
+type Good struct {
+	// Id 商品编号
+	Id   int64
+	Name string // 商品名称
+	Meta
+}
+
+// Meta 附加信息
+type Meta struct {
+	// 创建
+	// 时间
+	CreatedAt int64
+}
+
+// Service 商品服务
+type Service struct{}
+
+// Create creates a good
+// and returns its id.
+func (p *Service) Create() {}
+
+// Reader 读取商品
+type Reader interface {
+	// Get 获取商品
+	Get()
+}
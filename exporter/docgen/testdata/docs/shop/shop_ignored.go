@@ -0,0 +1,6 @@
+//go:build never
+
+package shop
+
+// Removed 不参与构建
+func Removed() {}
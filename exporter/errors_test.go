@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCodeMethods(codes ...*Code) []*Method {
+	return []*Method{{
+		Name:     "GetShop",
+		Path:     "/GetShop",
+		Method:   "GET",
+		Codes:    codes,
+		Envelope: &Envelope{Code: "code", Data: "data", Message: "msg"},
+	}}
+}
+
+// 合并生成的文件内容
+func testSDKContent(t *testing.T, lang string, methods []*Method) string {
+	files, err := NewSDK(methods, nil).Files(DefaultMakers(), lang, "sdk")
+	require.NoError(t, err)
+	var b strings.Builder
+	for _, v := range files {
+		b.WriteString(v.Content)
+	}
+	return b.String()
+}
+
+func TestRenderCodes(t *testing.T) {
+	methods := testCodeMethods(&Code{Status: 409, Code: "good.duplicate", Message: "it's \"dup\"\nretry"})
+	for _, c := range []struct {
+		lang string
+		want []string
+	}{
+		{"go", []string{
+			`CodeGoodDuplicate = "good.duplicate" // it's "dup" retry`,
+			`fields["code"]`,
+			`fields["msg"]`,
+		}},
+		{"axios", []string{
+			`GoodDuplicate = "good.duplicate", // it's "dup" retry`,
+			`"good.duplicate": "it's \"dup\"\nretry",`,
+			`body["msg"]`,
+			`toAPIError(e.response.status, e.response.data)`,
+		}},
+		{"angular", []string{
+			`"good.duplicate": "it's \"dup\"\nretry",`,
+			`catchError((e: any) => throwError(e instanceof HttpErrorResponse ? toAPIError(e.status, e.error) : e))`,
+		}},
+		{"umi", []string{
+			`"good.duplicate": "it's \"dup\"\nretry",`,
+			`toAPIError(e.response.status, e.data)`,
+		}},
+	} {
+		t.Run(c.lang, func(t *testing.T) {
+			content := testSDKContent(t, c.lang, methods)
+			for _, v := range c.want {
+				assert.Contains(t, content, v)
+			}
+			assert.NotContains(t, content, "&#39;")
+			assert.NotContains(t, content, "&quot;")
+		})
+	}
+}
+
+func TestCodeNameConflict(t *testing.T) {
+	assert.Equal(t, "NotFound", codeName("not-found"))
+	assert.Equal(t, "Code404", codeName("404"))
+
+	methods := testCodeMethods(&Code{Status: 404, Code: "not-found"}, &Code{Status: 404, Code: "NotFound"})
+	_, err := NewSDK(methods, nil).Files(DefaultMakers(), "go", "sdk")
+	assert.EqualError(t, err, "error code 'not-found' and 'NotFound' both map to identifier 'NotFound'")
+
+	methods = append(testCodeMethods(&Code{Status: 404, Code: "not-found"}), testCodeMethods(&Code{Status: 404, Code: "not-found"})...)
+	_, err = NewSDK(methods, nil).Files(DefaultMakers(), "go", "sdk")
+	assert.NoError(t, err)
+}
@@ -1,13 +1,15 @@
 package exporter
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/ttacon/chalk"
 	"github.com/utilslab/iam/assets"
+	"github.com/utilslab/iam/binding"
 	"github.com/utilslab/iam/utils"
-	"log"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"strings"
@@ -21,16 +23,29 @@ func NewExporter(addr string, options *Options) *Exporter {
 	return e
 }
 
+// Simulator 鉴权模拟器，由 API 注入，接收请求及其 JSON 请求体并返回模拟结果，调用者无权模拟时返回 ErrSimulateDenied
+type Simulator func(c *gin.Context, data []byte) (interface{}, error)
+
+var ErrSimulateDenied = errors.New("simulate access denied")
+
 type Exporter struct {
-	version string
-	addr    string
-	options *Options
-	Name    string
-	Package string
-	methods []*Method
-	basics  map[string]*BasicType
-	models  []*Field
-	makers  map[string]Maker
+	version     string
+	addr        string
+	options     *Options
+	Name        string
+	Package     string
+	methods     []*Method
+	permissions []*Permission
+	routes      []*Route
+	simulator   Simulator
+	basics      map[string]*BasicType
+	models      []*Field
+	makers      map[string]Maker
+	types       map[reflect.Type]*Field   // 结构体定义
+	typeList    []reflect.Type            // 结构体登记顺序
+	typeNames   map[string][]reflect.Type // 按名称归集的结构体，用于识别同名冲突
+	names       map[reflect.Type]string   // 已生成的结构体名称
+	refs        map[*Field]reflect.Type   // 以结构体名称为类型的字段，名称变化时同步修改
 }
 
 func (p *Exporter) Init(version string, methods []*Method, models *Fields) {
@@ -41,8 +56,23 @@ func (p *Exporter) Init(version string, methods []*Method, models *Fields) {
 	}
 }
 
-func (p Exporter) Run() {
+func (p *Exporter) SetPermissions(permissions []*Permission) {
+	p.permissions = permissions
+}
+
+func (p *Exporter) SetRoutes(routes []*Route) {
+	p.routes = routes
+}
+
+func (p *Exporter) SetSimulator(simulator Simulator) {
+	p.simulator = simulator
+}
+
+// Handler 构造导出器的 HTTP 处理器
+func (p Exporter) Handler() http.Handler {
 	engine := gin.Default()
+	// 鉴权模拟依赖调用者凭证，先于跨域中间件注册，不允许跨域调用
+	engine.POST("/simulate", p.simulateHandler)
 	engine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"*"},
@@ -53,9 +83,14 @@ func (p Exporter) Run() {
 	}))
 	engine.GET("/sdk", p.sdkHandler)
 	engine.GET("/protocol", p.protocolHandler)
+	engine.GET("/openapi.json", p.openAPIHandler)
+	engine.GET("/openapi.yaml", p.openAPIHandler)
+	engine.GET("/permissions", p.permissionsHandler)
+	engine.GET("/routes", p.routesHandler)
 	engine.StaticFS("/exporter", assets.Root)
+	// 使用相对地址跳转，以便挂载到其他服务的子路径下
 	engine.GET("/", func(c *gin.Context) {
-		c.Redirect(http.StatusMovedPermanently, "/exporter/index.html")
+		redirect(c, "exporter/index.html")
 	})
 	engine.GET("/:path", func(c *gin.Context) {
 		path := c.Param("path")
@@ -63,20 +98,26 @@ func (p Exporter) Run() {
 			path = "index.html"
 		}
 		if !strings.HasPrefix(path, "exporter") {
-			c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/exporter/%s", path))
+			redirect(c, fmt.Sprintf("exporter/%s", path))
 		}
 	})
 	engine.GET("/test", func(c *gin.Context) {
 		c.Request.URL.Path = "/sdk"
 		engine.HandleContext(c)
 	})
-	go func() {
-		p.printAddress()
-		err := engine.Run(p.addr)
-		if err != nil {
-			log.Panic("接口导出器启动失败", err)
-		}
-	}()
+	return engine
+}
+
+// 以相对地址跳转，http.Redirect 会将其转换为绝对地址
+func redirect(c *gin.Context, location string) {
+	c.Header("Location", location)
+	c.Status(http.StatusMovedPermanently)
+}
+
+// Server 构造导出器的 HTTP 服务
+func (p Exporter) Server() *http.Server {
+	p.printAddress()
+	return &http.Server{Addr: p.addr, Handler: p.Handler()}
 }
 
 // 打印 API 调试器访问地址
@@ -94,7 +135,7 @@ func (p Exporter) printAddress() {
 
 // 导出 SDK 代码
 func (p Exporter) sdkHandler(c *gin.Context) {
-	sdk := NewSDK(p.methods)
+	sdk := NewSDK(p.methods, p.models)
 	data, err := sdk.Make(p.makers, c.Query("lang"), c.Query("package"))
 	if err != nil {
 		_ = c.Error(err)
@@ -103,27 +144,99 @@ func (p Exporter) sdkHandler(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", data)
 }
 
+// SDK 在进程内生成 lang 对应的 SDK 文件，包含 Options 中注册的自定义生成器
+func (p Exporter) SDK(lang, pkg string) ([]*File, error) {
+	return NewSDK(p.methods, p.models).Files(p.makers, lang, pkg)
+}
+
 type ProtocolOutput struct {
-	Version string       `json:"version"`
-	Options *Options     `json:"options"`
-	Methods []*Method    `json:"methods"`
-	Structs []*Field     `json:"structs,omitempty"`
-	Basics  []*BasicType `json:"basics,omitempty"`
+	Version     string        `json:"version"`
+	Options     *Options      `json:"options"`
+	Methods     []*Method     `json:"methods"`
+	Structs     []*Field      `json:"structs,omitempty"`
+	Basics      []*BasicType  `json:"basics,omitempty"`
+	Permissions []*Permission `json:"permissions,omitempty"`
 }
 
 // 导出接口描述协议
 func (p Exporter) protocolHandler(c *gin.Context) {
+	c.JSON(200, p.Protocol(c.Query("lang")))
+}
+
+// Protocol 构造接口描述协议，lang 为 ts 时字段类型转换为 TypeScript 类型
+func (p Exporter) Protocol(lang string) *ProtocolOutput {
 	out := new(ProtocolOutput)
 	out.Version = p.version
 	out.Options = p.options
-	out.Methods = p.convertMethodTypes(c.Query("lang"))
+	out.Methods = p.convertMethodTypes(lang)
 	basics := new(BasicTypes)
 	for _, v := range p.basics {
 		basics.Add(v)
 	}
 	out.Basics = basics.All()
-	out.Structs = p.models
-	c.JSON(200, out)
+	out.Structs = p.convertStructTypes(lang)
+	out.Permissions = p.permissions
+	return out
+}
+
+// 导出 OpenAPI 文档，按路径后缀输出 JSON 或 YAML，通过 version 参数指定 3.0（默认）或 3.1
+func (p Exporter) openAPIHandler(c *gin.Context) {
+	doc, err := NewOpenAPI(p.Protocol("")).Document(c.Query("version"))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.HasSuffix(c.Request.URL.Path, ".yaml") {
+		c.YAML(http.StatusOK, doc)
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// 导出权限目录
+func (p Exporter) permissionsHandler(c *gin.Context) {
+	permissions := p.permissions
+	if permissions == nil {
+		permissions = make([]*Permission, 0)
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+type RoutesOutput struct {
+	Version string   `json:"version"`
+	Routes  []*Route `json:"routes"`
+}
+
+// 导出路由表
+func (p Exporter) routesHandler(c *gin.Context) {
+	out := &RoutesOutput{Version: p.version, Routes: p.routes}
+	if out.Routes == nil {
+		out.Routes = make([]*Route, 0)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// 模拟鉴权，解释鉴权结果
+func (p Exporter) simulateHandler(c *gin.Context) {
+	if p.simulator == nil {
+		c.String(http.StatusNotFound, "simulator not defined")
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := p.simulator(c, data)
+	if errors.Is(err, ErrSimulateDenied) {
+		c.String(http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 func (p Exporter) convertMethodTypes(lang string) []*Method {
@@ -142,6 +255,19 @@ func (p Exporter) convertMethodTypes(lang string) []*Method {
 	return methods
 }
 
+func (p Exporter) convertStructTypes(lang string) []*Field {
+	if lang != "ts" {
+		return p.models
+	}
+	structs := make([]*Field, 0, len(p.models))
+	for _, v := range p.models {
+		n := v.Fork()
+		p.toTsProtocolFieldType(n)
+		structs = append(structs, n)
+	}
+	return structs
+}
+
 func (p Exporter) toTsProtocolFieldType(field *Field) {
 	if field == nil {
 		return
@@ -174,13 +300,18 @@ func (p *Exporter) initBasicTypes() {
 	}
 }
 
-func (p *Exporter) initMakers() {
-	p.makers = map[string]Maker{
+// DefaultMakers 内置的 SDK 生成器，键为 SDK 生成目标
+func DefaultMakers() map[string]Maker {
+	return map[string]Maker{
 		"go":      GoMaker{},
 		"angular": AngularMaker{},
 		"umi":     UmiMaker{},
 		"axios":   AxiosMaker{},
 	}
+}
+
+func (p *Exporter) initMakers() {
+	p.makers = DefaultMakers()
 	if p.options != nil {
 		for k, v := range p.options.Makers {
 			p.makers[k] = v
@@ -188,8 +319,16 @@ func (p *Exporter) initMakers() {
 	}
 }
 
-// ReflectFields 反射转换输入输出的字段信息
+// ReflectFields 反射转换输入输出的字段信息，pt 为外层结构体类型，t 与之相同时仅以引用表示
+//
+// 仅展开 t 自身的成员，成员中的具名结构体以引用表示，其定义登记于 Models
 func (p *Exporter) ReflectFields(name, param, label string, validator *Validator, pt, t reflect.Type) (field *Field) {
+	expand := pt == nil || utils.TypeElem(pt) != utils.TypeElem(t)
+	return p.reflectFields(name, param, label, validator, t, expand)
+}
+
+// expand 为 false 时具名结构体仅以引用表示，并在首次出现时登记定义
+func (p *Exporter) reflectFields(name, param, label string, validator *Validator, t reflect.Type, expand bool) (field *Field) {
 	t = utils.TypeElem(t)
 	field = new(Field)
 	field.Name = name
@@ -199,6 +338,12 @@ func (p *Exporter) ReflectFields(name, param, label string, validator *Validator
 		field.Param = param
 	}
 	field.Label = label
+	if t == fileHeaderType {
+		field.Type = TypeFile
+		field.Form = FormFile
+		field.Validator = validator
+		return
+	}
 	basicType := p.getBasicType(t)
 	if basicType != nil {
 		field.Type = t.String()
@@ -210,55 +355,63 @@ func (p *Exporter) ReflectFields(name, param, label string, validator *Validator
 
 	if t.Kind() == reflect.Struct && basicType == nil {
 		field.Struct = true
+		field.StructDescription = typeDoc(t)
+		if t.Name() != "" {
+			field.Type = p.typeName(t)
+			field.Ref = field.Type
+			p.addRef(t, field)
+			if !expand {
+				p.defineType(t)
+				return
+			}
+			p.define(t, nil)
+		}
+		var forms []string
 		for i := 0; i < t.NumField(); i++ {
 			f := t.Field(i)
-			_elem := utils.TypeElem(f.Type)
-			_name := f.Name
-			_param := p.getParam(f)
-			_label := p.getFieldLabel(f)
-			_validator := p.getFieldValidator(f)
-			var _field *Field
-			if !elemEquals(pt, t) {
-				_field = p.ReflectFields(_name, _param, _label, _validator, t, _elem)
-				if _field.Struct || _field.Nested {
-					field.Nested = true
+			_field := p.reflectFields(f.Name, p.getParam(f), p.getFieldLabel(f), p.getFieldValidator(f), f.Type, false)
+			_field.In, _field.Key = p.getLocation(f)
+			_field.Description = fieldDoc(t, f.Name)
+			if _elem := utils.TypeElem(f.Type); _elem == fileHeaderType || (_field.Elem != nil && _field.Elem.Type == TypeFile) {
+				// 文件字段按 multipart 表单名提交
+				_field.In, _field.Key = InForm, p.getFormName(f)
+				_field.Param = _field.Key
+				_field.Type = TypeFile
+				if _field.Elem != nil {
+					_field.Elem.Form = ""
+					_field.Form = FormFile
 				}
-			} else {
-				_field = new(Field)
-				_field.Name = field.Name
-				_field.Type = "nested"
-				//_field.Origin = field.Origin
-				//_field.Param = _param
+			}
+			if _field.Struct || _field.Nested {
+				field.Nested = true
 			}
 			// 忽略 json:"-"
 			if _field.Param != "-" {
 				field.Fields = append(field.Fields, _field)
+				forms = append(forms, p.getFormName(f))
 			}
 		}
+		// 包含文件的结构体以 multipart 表单提交，服务端按 form 标签绑定，请求体字段同样按表单名提交
+		if field.HasFile() {
+			for i, v := range field.Fields {
+				if v.In == "" {
+					v.In, v.Key, v.Param = InForm, forms[i], forms[i]
+				}
+			}
+		}
+		if field.Ref != "" {
+			p.define(t, field)
+		}
 	} else if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
 		field.Array = true
-		elem := utils.TypeElem(t.Elem())
-		if !elemEquals(pt, t) {
-			field.Elem = p.ReflectFields("", "", label, validator, pt, elem)
-			if field.Elem.Struct || field.Elem.Nested {
-				field.Nested = true
-			}
-		} else {
-			field.Type = "nested"
+		field.Elem = p.reflectFields("", "", label, validator.elem(), t.Elem(), expand)
+		if field.Elem.Struct || field.Elem.Nested {
+			field.Nested = true
 		}
 	}
 	return
 }
 
-// 用于子字段是否与父类型形成递归
-func elemEquals(pt, t reflect.Type) bool {
-	if pt == nil {
-		return false
-	}
-	// 在使用的上下文中将 pt,t 都转为了非指针类型，故此不做判断
-	return pt.String() == t.String() && pt.PkgPath() == t.PkgPath()
-}
-
 func (p Exporter) getBasicType(t reflect.Type) *BasicType {
 	if p.basics == nil {
 		return nil
@@ -282,20 +435,37 @@ func (p Exporter) getFieldLabel(field reflect.StructField) string {
 	return field.Tag.Get("label")
 }
 
+// 按运行时校验所用的 binding 标签解析字段校验规则
 func (p Exporter) getFieldValidator(field reflect.StructField) (validator *Validator) {
-	required := strings.Contains(field.Tag.Get("validator"), "required")
-	if required {
-		validator = p.newIfNoValidator(validator)
-		validator.Required = true
+	if tag, ok := field.Tag.Lookup(binding.ValidateTag); ok {
+		return ParseValidator(tag)
 	}
 	return
 }
 
-func (p Exporter) newIfNoValidator(validator *Validator) *Validator {
-	if validator == nil {
-		validator = new(Validator)
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+// 获取字段在 multipart 表单中的名称，与 binding 一致取 form 标签，未设置时为字段名
+func (p Exporter) getFormName(field reflect.StructField) string {
+	if v := strings.Split(field.Tag.Get("form"), ",")[0]; v != "" && v != "-" {
+		return v
+	}
+	return field.Name
+}
+
+// 获取字段的参数位置，依次取 uri、header、query、cookie 标签
+func (p Exporter) getLocation(field reflect.StructField) (in, key string) {
+	for _, v := range []struct{ tag, in string }{
+		{"uri", InPath},
+		{"header", InHeader},
+		{"query", InQuery},
+		{"cookie", InCookie},
+	} {
+		if key = strings.Split(field.Tag.Get(v.tag), ",")[0]; key != "" && key != "-" {
+			return v.in, key
+		}
 	}
-	return validator
+	return "", ""
 }
 
 func (p Exporter) getParam(field reflect.StructField) string {
@@ -0,0 +1,100 @@
+package exporter
+
+import (
+	"mime/multipart"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadInput struct {
+	Title  string                  `json:"title"`
+	Avatar *multipart.FileHeader   `form:"avatar" json:"avatar"`
+	Photos []*multipart.FileHeader `form:"photos"`
+	Cover  *multipart.FileHeader
+}
+
+func TestReflectFileFields(t *testing.T) {
+	p := NewExporter("", nil)
+	input := p.ReflectFields("", "", "", nil, nil, reflect.TypeOf(uploadInput{}))
+	assert.True(t, input.HasFile())
+
+	fields := map[string]*Field{}
+	for _, v := range input.Fields {
+		fields[v.Name] = v
+	}
+	for _, c := range []struct {
+		name  string
+		key   string
+		array bool
+	}{
+		{"Avatar", "avatar", false},
+		{"Photos", "photos", true},
+		{"Cover", "Cover", false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			f := fields[c.name]
+			if assert.NotNil(t, f) {
+				assert.Equal(t, TypeFile, f.Type)
+				assert.Equal(t, FormFile, f.Form)
+				assert.Equal(t, InForm, f.In)
+				assert.Equal(t, c.key, f.Key)
+				assert.Equal(t, c.key, f.Param)
+				assert.Equal(t, c.array, f.Array)
+			}
+		})
+	}
+	// 其余请求体字段同样按表单名提交
+	title := fields["Title"]
+	assert.Equal(t, InForm, title.In)
+	assert.Equal(t, "Title", title.Key)
+	assert.Equal(t, "Title", title.Param)
+
+	plain := p.ReflectFields("", "", "", nil, nil, reflect.TypeOf(struct {
+		Name string `json:"name"`
+	}{}))
+	assert.False(t, plain.HasFile())
+}
+
+func TestRenderFileFieldType(t *testing.T) {
+	field := &Field{Type: TypeFile}
+	for _, c := range []struct {
+		lang string
+		want string
+	}{
+		{Go, "*File"},
+		{Ts, "Blob"},
+		{Angular, "Blob"},
+		{Axios, "Blob"},
+	} {
+		assert.Equal(t, c.want, getRenderFieldType(c.lang, field, nil), c.lang)
+	}
+}
+
+func TestRenderMultipart(t *testing.T) {
+	p := NewExporter("", nil)
+	methods := []*Method{{
+		Name:      "Upload",
+		Path:      "/Upload",
+		Method:    "POST",
+		Multipart: true,
+		Input:     p.ReflectFields("", "", "", nil, nil, reflect.TypeOf(uploadInput{})),
+	}}
+	for _, c := range []struct {
+		lang string
+		want []string
+	}{
+		{"go", []string{"multipartData{in}", `json:"Title" url:"Title"`, "Avatar *File", "Photos []*File"}},
+		{"axios", []string{"request.data = toFormData(params);", "Title?: string", "avatar?: Blob", "photos?: Blob[]"}},
+		{"angular", []string{"options.body = toFormData(params);"}},
+		{"umi", []string{"data: toFormData(params),"}},
+	} {
+		t.Run(c.lang, func(t *testing.T) {
+			content := testSDKContent(t, c.lang, methods)
+			for _, v := range c.want {
+				assert.Contains(t, content, v)
+			}
+		})
+	}
+}
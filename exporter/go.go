@@ -45,31 +45,58 @@ func (g GoMaker) Lang() string {
 	return Go
 }
 
-func (g GoMaker) Make(pkg string, methods []*Method) (files []*File, err error) {
-	data := MakeRenderData(g.Lang(), methods, GoNamer, GoTyper)
+func (g GoMaker) Make(pkg string, methods []*Method, models []*Field) (files []*File, err error) {
+	data := MakeRenderData(g.Lang(), methods, models, GoNamer, GoTyper)
+	for _, v := range data.Structs {
+		for _, vv := range v.Fields {
+			vv.Tag = goFieldTag(vv)
+		}
+	}
 	serviceFile := new(File)
 	serviceFile.Name = "service.make.go"
 	serviceFile.Content, err = Render(goServiceTpl, data, GoFormatter)
 	if err != nil {
 		return
 	}
+	errorsFile := new(File)
+	errorsFile.Name = "errors.make.go"
+	errorsFile.Content, err = Render(goErrorsTpl, data, GoFormatter)
+	if err != nil {
+		return
+	}
 	queryFile := new(File)
 	queryFile.Name = "values.make.go"
 	queryFile.Content = goValuesLibTpl
-	files = append(files, serviceFile, queryFile)
+	files = append(files, serviceFile, errorsFile, queryFile)
 	return
 }
 
+// 生成字段标签：请求元数据字段不随请求体与查询参数提交，以对应位置的标签标注，其余字段以 json、url 标签标注
+func goFieldTag(field *RenderField) string {
+	switch field.In {
+	case InPath:
+		return fmt.Sprintf(`json:"-" url:"-" uri:"%s"`, field.Key)
+	case InHeader, InQuery, InCookie:
+		return fmt.Sprintf(`json:"-" url:"-" %s:"%s"`, field.In, field.Key)
+	}
+	if field.Param == "" {
+		return ""
+	}
+	return fmt.Sprintf(`json:"%s" url:"%s"`, field.Param, field.Param)
+}
+
 const goServiceTpl = `
 package sdk
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -80,7 +107,7 @@ import (
 {% endfor %}
 
 type sdk interface {
-{% for method in Methods %}    {{ method.Name }}(ctx context.Context{% if method.InputType !='' %},in {{ method.InputType }}{% endif %})({% if method.OutputType !='' %}out {{ method.OutputType }},{% endif %} err error) {% if method.Description %}// {{ method.Description }}{% endif %}
+{% for method in Methods %}    {{ method.Name }}(ctx context.Context{% if method.InputType !='' %},in {{ method.InputType }}{% endif %})({% if method.Stream %}out <-chan {{ method.EventType }}, errc <-chan error,{% elif method.OutputType !='' %}out {{ method.OutputType }},{% endif %} err error) {% if method.Description %}// {{ _comment(method.Description) }}{% endif %}
 {% endfor %}
 }
 
@@ -103,11 +130,21 @@ func (s *SDK) RemoveHeader(key string) {
 	delete(s.headers, key)
 }
 
-func (s SDK) request(ctx context.Context, method string, path string, data interface{}, result interface{}) (err error) {
-	var req *http.Request
+// 按路径段替换路径参数，避免 :shop 误替换 :shopId 的前缀
+func fillPath(path string, values map[string]interface{}) string {
+	segments := strings.Split(path, "/")
+	for i, v := range segments {
+		if value, ok := values[v]; ok {
+			segments[i] = url.PathEscape(fmt.Sprint(value))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s SDK) newRequest(ctx context.Context, method string, path string, data interface{}) (req *http.Request, err error) {
 	remote := fmt.Sprintf("%s%s", s.host, path)
 	switch method {
-	case "GET", "DELETE":
+	case "GET", "DELETE", "HEAD", "OPTIONS":
 		if data != nil {
 			var values url.Values
 			values, err = Values(data)
@@ -122,9 +159,16 @@ func (s SDK) request(ctx context.Context, method string, path string, data inter
 			err = fmt.Errorf("build request error: %s", err)
 			return
 		}
-	case "PUT", "POST":
+	case "PUT", "POST", "PATCH":
 		var payload io.Reader
-		if data != nil {
+		contentType := "application/json"
+		if m, ok := data.(multipartData); ok {
+			payload, contentType, err = encodeMultipart(m.data)
+			if err != nil {
+				err = fmt.Errorf("encode data to multipart error: %s", err)
+				return
+			}
+		} else if data != nil {
 			var d []byte
 			d, err = json.Marshal(data)
 			if err != nil {
@@ -138,17 +182,60 @@ func (s SDK) request(ctx context.Context, method string, path string, data inter
 			err = fmt.Errorf("build request error: %s", err)
 			return
 		}
-		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Content-Type", contentType)
 	default:
 		err = fmt.Errorf("unsupport method: '%s'", method)
 		return
 	}
-	client := &http.Client{}
-	
 	for k, v := range s.headers {
 		req.Header.Add(k, v)
 	}
+	if m, ok := data.(multipartData); ok {
+		data = m.data
+	}
+	setLocations(req, data)
 	req = req.WithContext(ctx)
+	return
+}
+
+// setLocations 将声明 header、query、cookie 标签的入参字段写入请求对应位置，零值字段跳过
+func setLocations(req *http.Request, data interface{}) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	query := req.URL.Query()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		for f.Kind() == reflect.Ptr && !f.IsNil() {
+			f = f.Elem()
+		}
+		if f.IsZero() || f.Kind() == reflect.Ptr {
+			continue
+		}
+		value := fmt.Sprint(f.Interface())
+		if key := t.Field(i).Tag.Get("header"); key != "" {
+			req.Header.Set(key, value)
+		} else if key := t.Field(i).Tag.Get("query"); key != "" {
+			query.Add(key, value)
+		} else if key := t.Field(i).Tag.Get("cookie"); key != "" {
+			req.AddCookie(&http.Cookie{Name: key, Value: value})
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+func (s SDK) request(ctx context.Context, method string, path string, data interface{}, result interface{}, envelope string) (err error) {
+	req, err := s.newRequest(ctx, method, path, data)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{}
 	res, err := client.Do(req)
 	if err != nil {
 		err = fmt.Errorf("exec request error: %s", err)
@@ -163,7 +250,11 @@ func (s SDK) request(ctx context.Context, method string, path string, data inter
 		err = fmt.Errorf("read response body error: %s", err)
 		return
 	}
-	err = s.bindResult(res.Header, body, result)
+	if res.StatusCode >= http.StatusBadRequest {
+		err = newError(res.StatusCode, res.Header, body)
+		return
+	}
+	err = s.bindResult(res.Header, body, result, envelope)
 	if err != nil {
 		err = fmt.Errorf("bind result error: %s", err)
 		return
@@ -171,12 +262,180 @@ func (s SDK) request(ctx context.Context, method string, path string, data inter
 	return
 }
 
-func (s SDK) bindResult(header http.Header, body []byte, result interface{}) (err error) {
+// File 上传的文件
+type File struct {
+	Name   string
+	Reader io.Reader
+}
+
+// multipartData 标记以 multipart/form-data 提交的入参
+type multipartData struct {
+	data interface{}
+}
+
+// encodeMultipart 按 json 标签名（即服务端的表单名）将入参编码为 multipart 表单，文件以文件域提交，对象以 JSON 字符串提交
+func encodeMultipart(data interface{}) (body *bytes.Buffer, contentType string, err error) {
+	body = new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = t.Field(i).Name
+			}
+			err = writeMultipartField(writer, key, v.Field(i))
+			if err != nil {
+				return
+			}
+		}
+	}
+	err = writer.Close()
+	contentType = writer.FormDataContentType()
+	return
+}
+
+func writeMultipartField(writer *multipart.Writer, key string, v reflect.Value) error {
+	if f, ok := v.Interface().(*File); ok {
+		if f == nil || f.Reader == nil {
+			return nil
+		}
+		w, err := writer.CreateFormFile(key, f.Name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f.Reader)
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return writeMultipartField(writer, key, v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := writeMultipartField(writer, key, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct, reflect.Map:
+		d, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		return writer.WriteField(key, string(d))
+	default:
+		return writer.WriteField(key, fmt.Sprint(v.Interface()))
+	}
+}
+
+// download 请求文件或二进制流，由调用方关闭返回的 io.ReadCloser
+func (s SDK) download(ctx context.Context, method string, path string, data interface{}) (body io.ReadCloser, err error) {
+	req, err := s.newRequest(ctx, method, path, data)
+	if err != nil {
+		return
+	}
+	return s.open(req)
+}
+
+// subscribe 以 SSE 订阅事件流，返回的响应体由 readEvents 读取并关闭
+func (s SDK) subscribe(ctx context.Context, path string, data interface{}) (body io.ReadCloser, err error) {
+	req, err := s.newRequest(ctx, "GET", path, data)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	return s.open(req)
+}
+
+// readEvents 逐条读取 SSE 事件交由 handle 处理，直至 end 事件、error 事件或读取出错，返回的错误经事件流方法的 errc 交给调用方
+func readEvents(body io.ReadCloser, handle func(data []byte) error) error {
+	defer func() {
+		_ = body.Close()
+	}()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			switch event {
+			case "end":
+				return nil
+			case "error":
+				return fmt.Errorf("event stream error: %s", strings.Join(data, "\n"))
+			case "", "message":
+				if len(data) > 0 {
+					if err := handle([]byte(strings.Join(data, "\n"))); err != nil {
+						return err
+					}
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[6:])
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(line[5:], " "))
+		}
+	}
+	return scanner.Err()
+}
+
+// open 发送请求并返回响应体，状态码表示错误时解析为 *Error
+func (s SDK) open(req *http.Request) (body io.ReadCloser, err error) {
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("exec request error: %s", err)
+		return
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		defer func() {
+			_ = res.Body.Close()
+		}()
+		var d []byte
+		d, err = ioutil.ReadAll(res.Body)
+		if err != nil {
+			err = fmt.Errorf("read response body error: %s", err)
+			return
+		}
+		err = newError(res.StatusCode, res.Header, d)
+		return
+	}
+	body = res.Body
+	return
+}
+
+func (s SDK) bindResult(header http.Header, body []byte, result interface{}, envelope string) (err error) {
 	if result == nil {
 		return
 	}
 	contentType := header.Get("Content-Type")
 	if strings.HasPrefix(contentType, "application/json") {
+		if envelope != "" {
+			// 从响应包裹中取出数据
+			var fields map[string]json.RawMessage
+			err = json.Unmarshal(body, &fields)
+			if err != nil {
+				err = fmt.Errorf("unmarshal envelope error: %s", err)
+				return
+			}
+			body = fields[envelope]
+			if body == nil {
+				return
+			}
+		}
 		err = json.Unmarshal(body, result)
 		if err != nil {
 			err = fmt.Errorf("unmarshal data to result error: %s", err)
@@ -202,24 +461,125 @@ func (s SDK) bindResult(header http.Header, body []byte, result interface{}) (er
 }
 
 {% for method in Methods %}
-{% if method.Description %}// {{ method.Name }} {{ method.Description }}{% endif %}
-func (s SDK){{ method.Name }}(ctx context.Context{% if method.InputType !='' %},in {{ method.InputType }}{% endif %})({% if method.OutputType !='' %}out {{ method.OutputType }},{% endif %} err error){
+{% if method.Description %}// {{ method.Name }} {{ _comment(method.Description) }}{% endif %}
+func (s SDK){{ method.Name }}(ctx context.Context{% if method.InputType !='' %},in {{ method.InputType }}{% endif %})({% if method.Stream %}out <-chan {{ method.EventType }}, errc <-chan error,{% elif method.OutputType !='' %}out {{ method.OutputType }},{% endif %} err error){
     {% if method.OutputType !='' %}{% if method.OutputStruct %}out = new({{ _trimPrefix(method.OutputType,"*") }}){% endif %}{% endif %}
-    err = s.request(ctx, "{{ method.Method }}", "{{ method.Path }}",{% if method.InputType !='' %}in{% else %}nil{% endif %}{% if method.OutputType !='' %},{% if not method.OutputStruct %}&{% endif %}out{% else %}nil{% endif %})
+    path := "{{ method.Path }}"{% if method.PathParams %}
+    path = fillPath(path, map[string]interface{}{ {% for param in method.PathParams %}"{{ param.Placeholder }}": in.{{ param.Name }}, {% endfor %}}){% endif %}
+    {% if method.Stream %}body, err := s.subscribe(ctx, path, {% if method.InputType !='' %}in{% else %}nil{% endif %})
+    if err != nil {
+        return
+    }
+    ch := make(chan {{ method.EventType }})
+    ec := make(chan error, 1)
+    go func() {
+        defer close(ec)
+        defer close(ch)
+        ec <- readEvents(body, func(data []byte) error {
+            {% if method.EventStruct %}v := new({{ _trimPrefix(method.EventType,"*") }}){% else %}var v {{ method.EventType }}{% endif %}
+            if err := json.Unmarshal(data, {% if not method.EventStruct %}&{% endif %}v); err != nil {
+                return err
+            }
+            select {
+            case ch <- v:
+                return nil
+            case <-ctx.Done():
+                return ctx.Err()
+            }
+        })
+    }()
+    out, errc = ch, ec
+    return
+    {% elif method.Binary %}return s.download(ctx, "{{ method.Method }}", path, {% if method.Multipart %}multipartData{in}{% elif method.InputType !='' %}in{% else %}nil{% endif %}){% else %}err = s.request(ctx, "{{ method.Method }}", path, {% if method.Multipart %}multipartData{in}{% elif method.InputType !='' %}in{% else %}nil{% endif %}, {% if method.OutputType !='' %}{% if not method.OutputStruct %}&{% endif %}out{% else %}nil{% endif %}, "{{ method.Envelope }}")
     if err != nil{
 		return
     }
-	return
+	return{% endif %}
 }
 {% endfor %}
 
 {% for struct in Structs %}
-type {{ struct.Name }} struct {
-	{% for field in struct.Fields %} {{ field.Name }} {{ field.Type }} ` + "{% if field.Param != '' %}`" + `json:"{{ field.Param }}"` + "`{% endif %}" + `   {% if field.Description or field.Label %}// {{ field.Label }} {{ field.Description }}{% endif %}
+{% if struct.Description %}// {{ struct.Name }} {{ _comment(struct.Description) }}
+{% endif %}type {{ struct.Name }} struct {
+	{% for field in struct.Fields %} {{ field.Name }} {{ field.Type }} ` + "{% if field.Tag != '' %}`{{ field.Tag|safe }}`{% endif %}" + `   {% if field.Description or field.Label %}// {{ _comment(field.Label) }} {{ _comment(field.Description) }}{% endif %}
     {% endfor %}}
 {% endfor %}
 `
 
+const goErrorsTpl = `
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+{% if Codes %}
+const (
+{% for code in Codes %}    Code{{ code.Name }} = {{ _quote(code.Code) }} {% if code.Message %}// {{ _comment(code.Message) }}{% endif %}
+{% endfor %})
+{% endif %}
+
+// Error 服务端返回的错误
+type Error struct {
+	Status  int         ` + "`json:\"-\"`" + `
+	Code    string      ` + "`json:\"code\"`" + `
+	Message string      ` + "`json:\"message\"`" + `
+	Details interface{} ` + "`json:\"details,omitempty\"`" + `
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("status %d: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("status %d: %s: %s", e.Status, e.Code, e.Message)
+}
+
+// FieldError 入参校验失败时的字段错误，Field 为入参中的参数路径，如 items[0].sku
+type FieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Rule    string ` + "`json:\"rule\"`" + `
+	Param   string ` + "`json:\"param,omitempty\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// FieldErrors 解析入参校验失败（InvalidParams）错误中的字段错误，其余错误返回 nil
+func (e *Error) FieldErrors() (errs []*FieldError) {
+	if e.Code != "InvalidParams" || e.Details == nil {
+		return
+	}
+	data, err := json.Marshal(e.Details)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, &errs)
+	return
+}
+
+func newError(status int, header http.Header, body []byte) error {
+	e := &Error{Status: status}
+	if strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+{% if Envelope %}		// 从响应包裹中解析错误码与错误信息
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) == nil {
+			_ = json.Unmarshal(fields[{{ _quote(Envelope.Code) }}], &e.Code)
+			_ = json.Unmarshal(fields[{{ _quote(Envelope.Message) }}], &e.Message)
+			_ = json.Unmarshal(fields[{{ _quote(Envelope.Data) }}], &e.Details)
+			if e.Code != "" {
+				return e
+			}
+		}
+{% else %}		if json.Unmarshal(body, e) == nil && e.Code != "" {
+			return e
+		}
+{% endif %}	}
+	e.Message = string(body)
+	return e
+}
+`
+
 const goValuesLibTpl = `
 package sdk
 
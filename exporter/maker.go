@@ -1,6 +1,7 @@
 package exporter
 
+// Maker SDK 生成器，models 为结构体定义，方法中的具名结构体成员以 Ref 引用其中的定义
 type Maker interface {
 	Lang() string
-	Make(pkg string, methods []*Method) (files []*File, err error)
+	Make(pkg string, methods []*Method, models []*Field) (files []*File, err error)
 }
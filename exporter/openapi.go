@@ -0,0 +1,534 @@
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	OpenAPI30 = "3.0"
+	OpenAPI31 = "3.1"
+)
+
+// OpenAPIDocument OpenAPI 3.0/3.1 文档
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi" yaml:"openapi"`
+	Info       *OpenAPIInfo                            `json:"info" yaml:"info"`
+	Servers    []*OpenAPIServer                        `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths" yaml:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationId string                      `json:"operationId" yaml:"operationId"`
+	Summary     string                      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses" yaml:"responses"`
+	Permission  string                      `json:"x-permission,omitempty" yaml:"x-permission,omitempty"` // 权限名称
+	Resource    string                      `json:"x-resource,omitempty" yaml:"x-resource,omitempty"`     // 资源名称模板
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name" yaml:"name"`
+	In          string         `json:"in" yaml:"in"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description" yaml:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+// OpenAPISchema OpenAPI Schema 对象，ExclusiveMinimum、ExclusiveMaximum 在 3.0 中为布尔值，在 3.1 中为数值
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Title                string                    `json:"title,omitempty" yaml:"title,omitempty"`
+	Description          string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties interface{}               `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMinimum     interface{}               `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     interface{}               `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MinLength            *uint64                   `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *uint64                   `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems             *uint64                   `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *uint64                   `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// NewOpenAPI 由导出协议生成 OpenAPI 文档，协议可来自运行中的导出器或离线保存的 /protocol 输出
+func NewOpenAPI(protocol *ProtocolOutput) *OpenAPI {
+	p := &OpenAPI{protocol: protocol, basics: map[string]*BasicType{}, structs: map[string]*Field{}}
+	for _, v := range protocol.Basics {
+		p.basics[v.Type] = v
+	}
+	for _, v := range protocol.Structs {
+		if v.Ref != "" {
+			p.structs[v.Ref] = v
+		}
+	}
+	return p
+}
+
+type OpenAPI struct {
+	protocol *ProtocolOutput
+	basics   map[string]*BasicType
+	structs  map[string]*Field // 结构体定义，键为 Ref
+	version  string
+	schemas  map[string]*OpenAPISchema
+}
+
+// Document 生成指定版本（3.0 或 3.1）的文档，结构体以组件形式定义并以 $ref 引用
+func (p *OpenAPI) Document(version string) (doc *OpenAPIDocument, err error) {
+	switch version {
+	case "", OpenAPI30:
+		p.version = OpenAPI30
+	case OpenAPI31:
+		p.version = OpenAPI31
+	default:
+		err = fmt.Errorf("unsupported openapi version '%s'", version)
+		return
+	}
+	p.schemas = map[string]*OpenAPISchema{}
+	doc = &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    &OpenAPIInfo{Title: "API", Version: p.protocol.Version},
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+	if p.version == OpenAPI31 {
+		doc.OpenAPI = "3.1.0"
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	if options := p.protocol.Options; options != nil {
+		if options.Project != "" {
+			doc.Info.Title = options.Project
+		}
+		for _, v := range options.Envs {
+			doc.Servers = append(doc.Servers, &OpenAPIServer{URL: v.Host, Description: v.Name})
+		}
+	}
+	for _, method := range p.protocol.Methods {
+		path := openAPIPath(method.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(method.Method)] = p.operation(method)
+	}
+	if len(p.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: p.schemas}
+	}
+	return
+}
+
+// 将路径参数 :id、*path 转换为 {id}、{path}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, v := range segments {
+		if strings.HasPrefix(v, ":") || strings.HasPrefix(v, "*") {
+			segments[i] = fmt.Sprintf("{%s}", v[1:])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (p *OpenAPI) operation(method *Method) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationId: method.Name,
+		Summary:     method.Description,
+		Responses:   map[string]*OpenAPIResponse{},
+	}
+	for _, v := range p.protocol.Permissions {
+		if v.Method == method.Method && v.Path == method.Path {
+			op.Permission = v.Name
+			op.Resource = v.Resource
+			if v.Group != "" {
+				op.Tags = []string{v.Group}
+			}
+			break
+		}
+	}
+	p.addInput(op, method)
+	op.Responses[strconv.Itoa(http.StatusOK)] = p.successResponse(method)
+	p.addErrorResponses(op, method)
+	return op
+}
+
+// 路径、查询、请求头、Cookie 字段转换为参数，其余字段 GET、DELETE 作为查询参数，其他方法作为请求体
+func (p *OpenAPI) addInput(op *OpenAPIOperation, method *Method) {
+	input := method.Input
+	if input == nil {
+		return
+	}
+	if !input.Struct {
+		op.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]*OpenAPIMediaType{
+			"application/json": {Schema: p.schema(input)},
+		}}
+		return
+	}
+	pathFields := map[*Field]string{}
+	for _, segment := range strings.Split(method.Path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			if field := findPathField(input.Fields, segment[1:]); field != nil {
+				pathFields[field] = segment[1:]
+			}
+		}
+	}
+	query := method.Method == http.MethodGet || method.Method == http.MethodDelete
+	body := &Field{Type: input.Type, Struct: true}
+	for _, v := range input.Fields {
+		in, key := v.In, v.Key
+		if name, ok := pathFields[v]; ok {
+			in, key = InPath, name
+		}
+		switch in {
+		case InPath, InQuery, InHeader, InCookie:
+		case InForm:
+			body.Fields = append(body.Fields, v)
+			continue
+		default:
+			if !query {
+				body.Fields = append(body.Fields, v)
+				continue
+			}
+			in, key = InQuery, fieldParam(v)
+		}
+		param := &OpenAPIParameter{
+			Name:        key,
+			In:          in,
+			Description: joinText(v.Label, v.Description),
+			Required:    in == InPath || (v.Validator != nil && v.Validator.Required),
+			Schema:      p.schema(v),
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	if len(body.Fields) == 0 {
+		return
+	}
+	mime := "application/json"
+	if method.Multipart {
+		mime = "multipart/form-data"
+	}
+	var schema *OpenAPISchema
+	if len(body.Fields) == len(input.Fields) && !method.Multipart {
+		schema = p.schema(input)
+	} else {
+		// 部分字段位于路径、请求头等位置时，请求体以内联对象描述
+		schema = p.object(body)
+	}
+	op.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]*OpenAPIMediaType{mime: {Schema: schema}}}
+}
+
+func (p *OpenAPI) successResponse(method *Method) *OpenAPIResponse {
+	res := &OpenAPIResponse{Description: "OK"}
+	switch {
+	case method.Binary:
+		res.Content = map[string]*OpenAPIMediaType{
+			"application/octet-stream": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}},
+		}
+	case method.Event != nil:
+		res.Description = "Server-Sent Events, each data is a JSON encoded event"
+		res.Content = map[string]*OpenAPIMediaType{
+			"text/event-stream": {Schema: p.schema(method.Event)},
+		}
+	case method.Output != nil:
+		res.Content = map[string]*OpenAPIMediaType{
+			"application/json": {Schema: p.envelope(method.Envelope, method.Envelope.success(), p.schema(method.Output))},
+		}
+	case method.Envelope != nil:
+		res.Content = map[string]*OpenAPIMediaType{
+			"application/json": {Schema: p.envelope(method.Envelope, method.Envelope.success(), nil)},
+		}
+	}
+	return res
+}
+
+// 按状态汇总声明的错误码，错误结构为 {code, message, details}，设置响应包裹时按包裹结构描述
+func (p *OpenAPI) addErrorResponses(op *OpenAPIOperation, method *Method) {
+	codes := map[int][]*Code{}
+	var statuses []int
+	for _, v := range method.Codes {
+		if codes[v.Status] == nil {
+			statuses = append(statuses, v.Status)
+		}
+		codes[v.Status] = append(codes[v.Status], v)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		var names, messages []string
+		var details *OpenAPISchema
+		for _, v := range codes[status] {
+			names = append(names, v.Code)
+			if v.Message != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", v.Code, v.Message))
+			} else {
+				messages = append(messages, v.Code)
+			}
+			if v.Details != nil && details == nil {
+				details = p.schema(v.Details)
+			}
+		}
+		var schema *OpenAPISchema
+		if method.Envelope != nil {
+			schema = p.envelope(method.Envelope, names, details)
+		} else {
+			schema = &OpenAPISchema{
+				Type: "object",
+				Properties: map[string]*OpenAPISchema{
+					"code":    {Type: "string", Enum: names},
+					"message": {Type: "string"},
+				},
+				Required: []string{"code"},
+			}
+			if details != nil {
+				schema.Properties["details"] = details
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = &OpenAPIResponse{
+			Description: strings.Join(messages, "; "),
+			Content:     map[string]*OpenAPIMediaType{"application/json": {Schema: schema}},
+		}
+	}
+}
+
+// 以响应包裹描述数据，未设置包裹时返回数据本身
+func (p *OpenAPI) envelope(envelope *Envelope, codes []string, data *OpenAPISchema) *OpenAPISchema {
+	if envelope == nil {
+		return data
+	}
+	schema := &OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*OpenAPISchema{
+			envelope.Code:    {Type: "string", Enum: codes},
+			envelope.Message: {Type: "string"},
+		},
+		Required: []string{envelope.Code},
+	}
+	if envelope.TraceId != "" {
+		schema.Properties[envelope.TraceId] = &OpenAPISchema{Type: "string"}
+	}
+	if data != nil {
+		schema.Properties[envelope.Data] = data
+	}
+	return schema
+}
+
+func (p *Envelope) success() []string {
+	if p == nil {
+		return nil
+	}
+	return []string{p.Success}
+}
+
+// 转换字段的 Schema，具名结构体按协议中的定义注册为组件并以 $ref 引用
+func (p *OpenAPI) schema(field *Field) (schema *OpenAPISchema) {
+	switch {
+	case field.Array:
+		schema = &OpenAPISchema{Type: "array", Items: &OpenAPISchema{}}
+		if field.Elem != nil {
+			schema.Items = p.schema(field.Elem)
+		}
+	case field.Struct && field.Type == "Time" && len(field.Fields) == 0:
+		schema = &OpenAPISchema{Type: "string", Format: "date-time"}
+	case field.Struct && field.Ref == "":
+		// 匿名结构体无法作为组件复用，以内联对象描述
+		schema = p.object(field)
+	case field.Struct:
+		schema = p.ref(field.Ref)
+		if _, ok := p.schemas[field.Ref]; !ok {
+			def := field
+			if v, ok := p.structs[field.Ref]; ok && len(field.Fields) == 0 {
+				def = v
+			}
+			// 先占位，递归引用出现在外层结构体展开之后
+			p.schemas[field.Ref] = &OpenAPISchema{}
+			*p.schemas[field.Ref] = *p.object(def)
+			p.schemas[field.Ref].Description = def.StructDescription
+		}
+		if p.version == OpenAPI31 {
+			schema.Title = field.Label
+			schema.Description = field.Description
+		}
+		return
+	default:
+		schema = p.basicSchema(field.Type)
+	}
+	schema.Title = field.Label
+	schema.Description = field.Description
+	p.applyValidator(schema, field.Validator)
+	return
+}
+
+func (p *OpenAPI) ref(name string) *OpenAPISchema {
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (p *OpenAPI) object(field *Field) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for _, v := range field.Fields {
+		name := fieldParam(v)
+		schema.Properties[name] = p.schema(v)
+		if v.Validator != nil && v.Validator.Required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+func fieldParam(field *Field) string {
+	if field.Param != "" {
+		return field.Param
+	}
+	return field.Name
+}
+
+func (p *OpenAPI) basicSchema(typ string) *OpenAPISchema {
+	switch typ {
+	case "bool":
+		return &OpenAPISchema{Type: "boolean"}
+	case "int8", "int16", "int32", "uint8", "uint16":
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case "int", "int64", "uint", "uint32", "uint64":
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case "float64":
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case "string":
+		return &OpenAPISchema{Type: "string"}
+	case TypeFile:
+		return &OpenAPISchema{Type: "string", Format: "binary"}
+	}
+	if strings.HasPrefix(typ, "map[") {
+		return &OpenAPISchema{Type: "object", AdditionalProperties: true}
+	}
+	if basic, ok := p.basics[typ]; ok {
+		// 基础类型按 TypeScript 映射推断 JSON 类型
+		if lib := basic.getMapping(Ts); lib != nil {
+			switch lib.Type {
+			case "number", "boolean":
+				return &OpenAPISchema{Type: lib.Type}
+			}
+		}
+		return &OpenAPISchema{Type: "string"}
+	}
+	return &OpenAPISchema{}
+}
+
+// 按字段类型转换校验规则：字符串比较长度，数组比较元素个数，其余比较取值
+func (p *OpenAPI) applyValidator(schema *OpenAPISchema, v *Validator) {
+	if v == nil {
+		return
+	}
+	schema.Enum = v.Enums
+	schema.Pattern = v.Pattern
+	switch v.Format {
+	case FormatEmail:
+		schema.Format = "email"
+	case FormatUrl:
+		schema.Format = "uri"
+	}
+	switch schema.Type {
+	case "string", "array":
+		min, max := schema.setMinLength, schema.setMaxLength
+		if schema.Type == "array" {
+			min, max = schema.setMinItems, schema.setMaxItems
+		}
+		if v.Min != nil {
+			min(*v.Min)
+		}
+		if v.Gt != nil {
+			min(*v.Gt + 1)
+		}
+		if v.Max != nil {
+			max(*v.Max)
+		}
+		if v.Lt != nil {
+			max(*v.Lt - 1)
+		}
+		if v.Len != nil {
+			min(*v.Len)
+			max(*v.Len)
+		}
+	case "integer", "number":
+		schema.Minimum, schema.Maximum = v.Min, v.Max
+		if v.Len != nil {
+			schema.Minimum, schema.Maximum = v.Len, v.Len
+		}
+		if p.version == OpenAPI31 {
+			if v.Gt != nil {
+				schema.ExclusiveMinimum = *v.Gt
+			}
+			if v.Lt != nil {
+				schema.ExclusiveMaximum = *v.Lt
+			}
+		} else {
+			if v.Gt != nil {
+				schema.Minimum, schema.ExclusiveMinimum = v.Gt, true
+			}
+			if v.Lt != nil {
+				schema.Maximum, schema.ExclusiveMaximum = v.Lt, true
+			}
+		}
+	}
+}
+
+func (p *OpenAPISchema) setMinLength(v float64) { p.MinLength = uint64Ptr(v) }
+func (p *OpenAPISchema) setMaxLength(v float64) { p.MaxLength = uint64Ptr(v) }
+func (p *OpenAPISchema) setMinItems(v float64)  { p.MinItems = uint64Ptr(v) }
+func (p *OpenAPISchema) setMaxItems(v float64)  { p.MaxItems = uint64Ptr(v) }
+
+func uint64Ptr(v float64) *uint64 {
+	if v < 0 {
+		v = 0
+	}
+	n := uint64(v)
+	return &n
+}
+
+func joinText(items ...string) string {
+	var texts []string
+	for _, v := range items {
+		if v != "" {
+			texts = append(texts, v)
+		}
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,195 @@
+package exporter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 分类为递归结构：子分类引用自身，递归出现时仅有引用
+func testCateField() *Field {
+	return &Field{Name: "Cate", Type: "*Cate", Struct: true, Ref: "Cate", StructDescription: "分类", Fields: []*Field{
+		{Name: "Id", Param: "id", Type: "int64"},
+		{Name: "Name", Param: "name", Label: "名称", Type: "string", Validator: &Validator{Required: true, Min: testNumber(2), Max: testNumber(20)}},
+		{Name: "Children", Param: "children", Array: true, Elem: &Field{Type: "*Cate", Struct: true, Nested: true, Ref: "Cate"}},
+	}}
+}
+
+func testOpenAPIProtocol() *ProtocolOutput {
+	return &ProtocolOutput{
+		Version: "1.2.0",
+		Options: &Options{Project: "shop", Envs: []Env{{Name: "dev", Host: "http://localhost:8080"}}},
+		Methods: []*Method{
+			{
+				Name:   "GetGood",
+				Path:   "/shops/:shopId/goods/:goodId",
+				Method: "GET",
+				Input: &Field{Name: "GetGoodIn", Type: "*GetGoodIn", Struct: true, Ref: "GetGoodIn", Fields: []*Field{
+					{Name: "ShopId", Param: "shopId", Type: "int64", In: InPath, Key: "shopId"},
+					{Name: "GoodId", Param: "goodId", Type: "int64"},
+					{Name: "Tenant", Param: "tenant", Type: "string", In: InHeader, Key: "X-Tenant", Validator: &Validator{Required: true}},
+					{Name: "Color", Param: "color", Label: "颜色", Description: "商品颜色", Type: "string", Validator: &Validator{Enums: []string{"red", "blue"}}},
+				}},
+				Output: testCateField(),
+				Codes: []*Code{
+					{Status: 404, Code: "GoodNotFound", Message: "good not found"},
+					{Status: 404, Code: "ShopNotFound"},
+					{Status: 400, Code: "InvalidParams", Message: "invalid params", Details: &Field{Array: true, Elem: &Field{Type: "*FieldError", Struct: true, Ref: "FieldError", Fields: []*Field{
+						{Name: "Field", Param: "field", Type: "string"},
+					}}}},
+				},
+			},
+			{
+				Name:     "CreateCate",
+				Path:     "/cates",
+				Method:   "POST",
+				Input:    testCateField(),
+				Output:   &Field{Type: "int64"},
+				Envelope: &Envelope{Code: "code", Data: "data", Message: "msg", TraceId: "traceId", Success: "OK"},
+				Codes:    []*Code{{Status: 409, Code: "CateDuplicate"}},
+			},
+			{
+				Name:   "Upload",
+				Path:   "/shops/:shopId/files",
+				Method: "POST",
+				Input: &Field{Name: "UploadIn", Type: "*UploadIn", Struct: true, Ref: "UploadIn", Fields: []*Field{
+					{Name: "ShopId", Param: "shopId", Type: "int64", In: InPath, Key: "shopId"},
+					{Name: "File", Param: "file", Type: TypeFile, In: InForm, Key: "file"},
+				}},
+				Multipart: true,
+			},
+			{Name: "Download", Path: "/files/*path", Method: "GET", Binary: true},
+			{Name: "Watch", Path: "/watch", Method: "GET", Event: &Field{Type: "string"}},
+		},
+		Permissions: []*Permission{{Name: "good:GetGood", Group: "good", Method: "GET", Path: "/shops/:shopId/goods/:goodId", Resource: "shop/$shopId"}},
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/shops/{shopId}/files/{path}", openAPIPath("/shops/:shopId/files/*path"))
+	assert.Equal(t, "/shops", openAPIPath("/shops"))
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc, err := NewOpenAPI(testOpenAPIProtocol()).Document(OpenAPI30)
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, &OpenAPIInfo{Title: "shop", Version: "1.2.0"}, doc.Info)
+	assert.Equal(t, []*OpenAPIServer{{URL: "http://localhost:8080", Description: "dev"}}, doc.Servers)
+
+	get := doc.Paths["/shops/{shopId}/goods/{goodId}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "GetGood", get.OperationId)
+	assert.Equal(t, []string{"good"}, get.Tags)
+	assert.Equal(t, "good:GetGood", get.Permission)
+	assert.Equal(t, "shop/$shopId", get.Resource)
+	assert.Nil(t, get.RequestBody)
+	assert.Equal(t, []*OpenAPIParameter{
+		{Name: "shopId", In: InPath, Required: true, Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+		{Name: "goodId", In: InPath, Required: true, Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+		{Name: "X-Tenant", In: InHeader, Required: true, Schema: &OpenAPISchema{Type: "string"}},
+		{Name: "color", In: InQuery, Description: "颜色 商品颜色", Schema: &OpenAPISchema{Type: "string", Title: "颜色", Description: "商品颜色", Enum: []string{"red", "blue"}}},
+	}, get.Parameters)
+	assert.Equal(t, &OpenAPISchema{Ref: "#/components/schemas/Cate"}, get.Responses["200"].Content["application/json"].Schema)
+	// 同一状态的错误码合并描述
+	notFound := get.Responses["404"]
+	require.NotNil(t, notFound)
+	assert.Equal(t, "GoodNotFound: good not found; ShopNotFound", notFound.Description)
+	assert.Equal(t, []string{"GoodNotFound", "ShopNotFound"}, notFound.Content["application/json"].Schema.Properties["code"].Enum)
+	invalid := get.Responses["400"].Content["application/json"].Schema
+	assert.Equal(t, &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/FieldError"}}, invalid.Properties["details"])
+
+	// 递归结构体注册为组件，成员中的递归引用不再展开
+	require.NotNil(t, doc.Components)
+	cate := doc.Components.Schemas["Cate"]
+	require.NotNil(t, cate)
+	assert.Equal(t, "object", cate.Type)
+	assert.Equal(t, "分类", cate.Description)
+	assert.Equal(t, []string{"name"}, cate.Required)
+	assert.Equal(t, &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/Cate"}}, cate.Properties["children"])
+	name := cate.Properties["name"]
+	assert.Equal(t, "名称", name.Title)
+	assert.Equal(t, uint64(2), *name.MinLength)
+	assert.Equal(t, uint64(20), *name.MaxLength)
+	assert.Contains(t, doc.Components.Schemas, "FieldError")
+
+	// 请求体使用组件引用，响应按包裹结构描述
+	create := doc.Paths["/cates"]["post"]
+	require.NotNil(t, create.RequestBody)
+	assert.Equal(t, &OpenAPISchema{Ref: "#/components/schemas/Cate"}, create.RequestBody.Content["application/json"].Schema)
+	ok := create.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, []string{"code"}, ok.Required)
+	assert.Equal(t, []string{"OK"}, ok.Properties["code"].Enum)
+	assert.Equal(t, &OpenAPISchema{Type: "integer", Format: "int64"}, ok.Properties["data"])
+	assert.Contains(t, ok.Properties, "msg")
+	assert.Contains(t, ok.Properties, "traceId")
+	assert.Equal(t, []string{"CateDuplicate"}, create.Responses["409"].Content["application/json"].Schema.Properties["code"].Enum)
+
+	// 部分字段位于路径时，multipart 请求体以内联对象描述
+	upload := doc.Paths["/shops/{shopId}/files"]["post"]
+	require.Len(t, upload.Parameters, 1)
+	assert.Equal(t, "shopId", upload.Parameters[0].Name)
+	form := upload.RequestBody.Content["multipart/form-data"].Schema
+	assert.Equal(t, &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+		"file": {Type: "string", Format: "binary"},
+	}}, form)
+
+	download := doc.Paths["/files/{path}"]["get"]
+	assert.Equal(t, &OpenAPISchema{Type: "string", Format: "binary"}, download.Responses["200"].Content["application/octet-stream"].Schema)
+	watch := doc.Paths["/watch"]["get"]
+	assert.Equal(t, &OpenAPISchema{Type: "string"}, watch.Responses["200"].Content["text/event-stream"].Schema)
+}
+
+func TestOpenAPIVersion(t *testing.T) {
+	protocol := &ProtocolOutput{Methods: []*Method{{
+		Name:   "ListGood",
+		Path:   "/goods",
+		Method: "GET",
+		Input: &Field{Name: "ListGoodIn", Type: "*ListGoodIn", Struct: true, Fields: []*Field{
+			{Name: "Page", Param: "page", Type: "int", Validator: &Validator{Gt: testNumber(0), Lt: testNumber(100)}},
+			{Name: "Ids", Param: "ids", Array: true, Elem: &Field{Type: "int64"}, Validator: &Validator{Max: testNumber(5)}},
+			{Name: "Email", Param: "email", Type: "string", Validator: &Validator{Format: FormatEmail, Pattern: "^a"}},
+		}},
+	}}}
+	for _, c := range []struct {
+		version string
+		openapi string
+		page    *OpenAPISchema
+	}{
+		{"", "3.0.3", &OpenAPISchema{Type: "integer", Format: "int64", Minimum: testNumber(0), Maximum: testNumber(100), ExclusiveMinimum: true, ExclusiveMaximum: true}},
+		{OpenAPI31, "3.1.0", &OpenAPISchema{Type: "integer", Format: "int64", ExclusiveMinimum: float64(0), ExclusiveMaximum: float64(100)}},
+	} {
+		doc, err := NewOpenAPI(protocol).Document(c.version)
+		require.NoError(t, err)
+		assert.Equal(t, c.openapi, doc.OpenAPI)
+		assert.Equal(t, &OpenAPIInfo{Title: "API", Version: "0.0.0"}, doc.Info)
+		assert.Nil(t, doc.Components)
+		params := doc.Paths["/goods"]["get"].Parameters
+		require.Len(t, params, 3)
+		assert.Equal(t, c.page, params[0].Schema, c.version)
+		assert.Equal(t, uint64(5), *params[1].Schema.MaxItems)
+		assert.Equal(t, &OpenAPISchema{Type: "string", Format: "email", Pattern: "^a"}, params[2].Schema)
+	}
+	_, err := NewOpenAPI(protocol).Document("2.0")
+	assert.EqualError(t, err, "unsupported openapi version '2.0'")
+}
+
+// 离线保存的协议经 JSON 往返后生成的文档与运行时一致
+func TestOpenAPIOffline(t *testing.T) {
+	protocol := testOpenAPIProtocol()
+	want, err := NewOpenAPI(protocol).Document(OpenAPI31)
+	require.NoError(t, err)
+	data, err := json.Marshal(protocol)
+	require.NoError(t, err)
+	offline := new(ProtocolOutput)
+	require.NoError(t, json.Unmarshal(data, offline))
+	got, err := NewOpenAPI(offline).Document(OpenAPI31)
+	require.NoError(t, err)
+	wantData, err := json.Marshal(want)
+	require.NoError(t, err)
+	gotData, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(wantData), string(gotData))
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPathMethod() *Method {
+	return &Method{
+		Name:   "GetGood",
+		Path:   "/shop/:shop/good/:shopId",
+		Method: "GET",
+		Input: &Field{Name: "GetGoodIn", Type: "GetGoodIn", Struct: true, Fields: []*Field{
+			{Name: "Shop", Param: "shop", Type: "string", In: InPath, Key: "shop"},
+			{Name: "ShopId", Param: "shopId", Type: "int64", In: InPath, Key: "shopId"},
+		}},
+	}
+}
+
+func TestMakeRenderPathParams(t *testing.T) {
+	params := makeRenderPathParams(testPathMethod(), EmptyNamer)
+	assert.Equal(t, []*RenderPathParam{
+		{Placeholder: ":shop", Name: "Shop", Param: "shop"},
+		{Placeholder: ":shopId", Name: "ShopId", Param: "shopId"},
+	}, params)
+	assert.Empty(t, makeRenderPathParams(&Method{Path: "/shop/:shop"}, EmptyNamer))
+}
+
+func TestRenderPath(t *testing.T) {
+	methods := []*Method{testPathMethod(), {Name: "ListShop", Path: "/shop", Method: "GET"}}
+	for _, c := range []struct {
+		lang string
+		want []string
+	}{
+		{"go", []string{
+			`path = fillPath(path, map[string]interface{}{":shop": in.Shop, ":shopId": in.ShopId})`,
+			`path := "/shop"` + "\n",
+		}},
+		{"axios", []string{
+			`request.url = fillPath('/shop/:shop/good/:shopId', { ':shop': params.shop, ':shopId': params.shopId, });`,
+			`request.url = '/shop';`,
+		}},
+		{"angular", []string{
+			`this.host+fillPath('/shop/:shop/good/:shopId', { ':shop': params.shop, ':shopId': params.shopId, })`,
+		}},
+		{"umi", []string{
+			`request<null>(fillPath('/shop/:shop/good/:shopId', { ':shop': params.shop, ':shopId': params.shopId, }), {`,
+		}},
+	} {
+		t.Run(c.lang, func(t *testing.T) {
+			content := testSDKContent(t, c.lang, methods)
+			for _, v := range c.want {
+				assert.Contains(t, content, v)
+			}
+			assert.NotContains(t, content, ".replace(':")
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package exporter
+
+import (
+	"github.com/utilslab/iam/utils"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RegisterTypes 预先登记类型及其成员中的具名结构体，使不同包的同名结构体在生成名称前即可识别冲突
+func (p *Exporter) RegisterTypes(types ...reflect.Type) {
+	visited := map[reflect.Type]bool{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		t = utils.TypeElem(t)
+		if visited[t] || t == fileHeaderType || p.getBasicType(t) != nil {
+			return
+		}
+		visited[t] = true
+		switch t.Kind() {
+		case reflect.Struct:
+			if t.Name() != "" {
+				p.addTypeName(t)
+			}
+			for i := 0; i < t.NumField(); i++ {
+				walk(t.Field(i).Type)
+			}
+		case reflect.Slice, reflect.Array:
+			walk(t.Elem())
+		}
+	}
+	for _, t := range types {
+		walk(t)
+	}
+}
+
+// Models 返回已登记的结构体定义，每个结构体仅出现一次，成员中的具名结构体以 Ref 引用
+func (p Exporter) Models() *Fields {
+	models := new(Fields)
+	for _, t := range p.typeList {
+		if def := p.types[t]; def != nil {
+			models.Add(def)
+		}
+	}
+	return models
+}
+
+// 登记结构体名称，新增结构体时重新生成全部名称
+func (p *Exporter) addTypeName(t reflect.Type) {
+	if p.typeNames == nil {
+		p.typeNames = map[string][]reflect.Type{}
+	}
+	for _, v := range p.typeNames[t.Name()] {
+		if v == t {
+			return
+		}
+	}
+	p.typeNames[t.Name()] = append(p.typeNames[t.Name()], t)
+	p.resolveNames()
+}
+
+// 结构体的名称，与其他包的同名结构体冲突时以包名限定，如 billing.User 为 BillingUser
+func (p *Exporter) typeName(t reflect.Type) string {
+	p.addTypeName(t)
+	return p.names[t]
+}
+
+// 生成全部结构体的名称，结果与登记顺序无关
+//
+// 同名结构体均以能相互区分的最短包路径后缀限定，限定后的名称与其他结构体的名称相同时继续加长后缀；
+// 名称变化时同步修改已生成的引用
+func (p *Exporter) resolveNames() {
+	taken := map[string]bool{}
+	var collisions []string
+	for name, types := range p.typeNames {
+		if len(types) == 1 {
+			taken[name] = true
+		} else {
+			collisions = append(collisions, name)
+		}
+	}
+	names := map[reflect.Type]string{}
+	for name, types := range p.typeNames {
+		if len(types) == 1 {
+			names[types[0]] = name
+		}
+	}
+	sort.Strings(collisions)
+	for _, name := range collisions {
+		types := append([]reflect.Type{}, p.typeNames[name]...)
+		sort.Slice(types, func(i, j int) bool {
+			return types[i].PkgPath() < types[j].PkgPath()
+		})
+		for _, t := range types {
+			names[t] = qualifiedName(t, types, taken)
+			taken[names[t]] = true
+		}
+	}
+	for t, name := range names {
+		if old, ok := p.names[t]; ok && old != name {
+			p.renameRefs(t, name)
+		}
+	}
+	p.names = names
+}
+
+// 取能区分全部同名结构体且未被占用的最短包路径后缀限定名称，完整包路径仍被占用时追加序号
+func qualifiedName(t reflect.Type, colliders []reflect.Type, taken map[string]bool) string {
+	segments := strings.Split(t.PkgPath(), "/")
+	for k := 1; k <= len(segments); k++ {
+		name := qualify(t, k)
+		if taken[name] {
+			continue
+		}
+		unique := true
+		for _, v := range colliders {
+			if v != t && qualify(v, k) == name {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return name
+		}
+	}
+	name := qualify(t, len(segments))
+	for i := 2; ; i++ {
+		if v := name + strconv.Itoa(i); !taken[v] {
+			return v
+		}
+	}
+}
+
+// 记录以结构体名称为类型的字段，名称变化时同步修改
+func (p *Exporter) addRef(t reflect.Type, field *Field) {
+	if p.refs == nil {
+		p.refs = map[*Field]reflect.Type{}
+	}
+	p.refs[field] = t
+}
+
+// 复制字段时同步记录副本中的引用
+func (p *Exporter) forkRefs(origin, fork *Field) {
+	if t, ok := p.refs[origin]; ok {
+		p.refs[fork] = t
+	}
+	for i, v := range origin.Fields {
+		p.forkRefs(v, fork.Fields[i])
+	}
+	if origin.Elem != nil {
+		p.forkRefs(origin.Elem, fork.Elem)
+	}
+}
+
+func (p *Exporter) renameRefs(t reflect.Type, name string) {
+	for field, v := range p.refs {
+		if v == t {
+			field.Type, field.Ref = name, name
+		}
+	}
+}
+
+func qualify(t reflect.Type, k int) string {
+	segments := strings.Split(t.PkgPath(), "/")
+	if k > len(segments) {
+		k = len(segments)
+	}
+	var b strings.Builder
+	for _, segment := range segments[len(segments)-k:] {
+		upper := true
+		for _, r := range segment {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(t.Name())
+	return b.String()
+}
+
+// 展开具名结构体的成员并登记定义，已登记或正在展开时不再重复展开
+func (p *Exporter) defineType(t reflect.Type) {
+	if _, ok := p.types[t]; ok {
+		return
+	}
+	p.reflectFields("", "", "", nil, t, true)
+}
+
+// 登记结构体定义，定义中的具名结构体成员均为引用；field 为 nil 时按展开顺序占位，以便展开成员时识别递归
+func (p *Exporter) define(t reflect.Type, field *Field) {
+	if p.types == nil {
+		p.types = map[reflect.Type]*Field{}
+	}
+	def, ok := p.types[t]
+	if def != nil {
+		return
+	}
+	if !ok {
+		p.typeList = append(p.typeList, t)
+	}
+	if field == nil {
+		p.types[t] = nil
+		return
+	}
+	def = &Field{
+		Name:              t.Name(),
+		Type:              field.Type,
+		Struct:            true,
+		Nested:            field.Nested,
+		Ref:               field.Ref,
+		Package:           t.PkgPath(),
+		StructDescription: field.StructDescription,
+	}
+	p.addRef(t, def)
+	for _, v := range field.Fields {
+		fork := v.Fork()
+		p.forkRefs(v, fork)
+		def.Fields = append(def.Fields, fork)
+	}
+	p.types[t] = def
+}
@@ -0,0 +1,196 @@
+package exporter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter/testdata/registry/account"
+	"github.com/utilslab/iam/exporter/testdata/registry/billing"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	Name    string       `json:"name"`
+	Home    *testAddress `json:"home"`
+	Work    testAddress  `json:"work"`
+	Friends []*testUser  `json:"friends"`
+	Extra   struct {
+		Note string `json:"note"`
+	} `json:"extra"`
+}
+
+func TestReflectReferences(t *testing.T) {
+	e := NewExporter("", nil)
+	field := e.ReflectFields("", "", "", nil, nil, reflect.TypeOf(&testUser{}))
+
+	// 方法仅展开自身成员，成员中的具名结构体以引用表示
+	assert.Equal(t, "testUser", field.Ref)
+	require.Len(t, field.Fields, 5)
+	home := field.Fields[1]
+	assert.Equal(t, &Field{Name: "Home", Param: "home", Type: "testAddress", Struct: true, Ref: "testAddress"}, home)
+	assert.Equal(t, "testAddress", field.Fields[2].Ref)
+	assert.Empty(t, field.Fields[2].Fields)
+	friends := field.Fields[3]
+	assert.True(t, friends.Array)
+	assert.Equal(t, "testUser", friends.Elem.Ref)
+	assert.Empty(t, friends.Elem.Fields)
+	// 匿名结构体无法引用，内联展开
+	extra := field.Fields[4]
+	assert.Empty(t, extra.Ref)
+	require.Len(t, extra.Fields, 1)
+	assert.Equal(t, "note", extra.Fields[0].Param)
+
+	// 每个具名结构体仅定义一次，定义中的成员同样为引用
+	models := e.Models().All()
+	require.Len(t, models, 2)
+	assert.Equal(t, "testUser", models[0].Ref)
+	assert.Equal(t, reflect.TypeOf(testUser{}).PkgPath(), models[0].Package)
+	require.Len(t, models[0].Fields, 5)
+	assert.Empty(t, models[0].Fields[1].Fields)
+	assert.Equal(t, "testUser", models[0].Fields[3].Elem.Ref)
+	assert.Equal(t, []*Field{{Name: "City", Param: "city", Type: "string"}}, models[1].Fields)
+	assert.Equal(t, "testAddress", models[1].Ref)
+
+	// 再次反射不重复登记
+	e.ReflectFields("", "", "", nil, nil, reflect.TypeOf(testAddress{}))
+	assert.Len(t, e.Models().All(), 2)
+}
+
+// 与 billing.User 的限定名称相同的结构体
+type BillingUser struct {
+	Name string `json:"name"`
+}
+
+type testUsers struct {
+	Billing *billing.User `json:"billing"`
+}
+
+func TestTypeName(t *testing.T) {
+	e := NewExporter("", nil)
+	e.RegisterTypes(reflect.TypeOf(json.Decoder{}), reflect.TypeOf(xml.Decoder{}), reflect.TypeOf(testAddress{}))
+	assert.Equal(t, "JsonDecoder", e.typeName(reflect.TypeOf(json.Decoder{})))
+	assert.Equal(t, "XmlDecoder", e.typeName(reflect.TypeOf(xml.Decoder{})))
+	assert.Equal(t, "testAddress", e.typeName(reflect.TypeOf(testAddress{})))
+
+	// 同名结构体均被限定，与登记顺序无关，已生成的引用与定义同步修改
+	billingUser, accountUser := reflect.TypeOf(billing.User{}), reflect.TypeOf(account.User{})
+	e = NewExporter("", nil)
+	field := e.ReflectFields("", "", "", nil, nil, reflect.TypeOf(testUsers{}))
+	assert.Equal(t, "User", field.Fields[0].Ref)
+	e.RegisterTypes(accountUser)
+	assert.Equal(t, "BillingUser", e.typeName(billingUser))
+	assert.Equal(t, "AccountUser", e.typeName(accountUser))
+	assert.Equal(t, &Field{Name: "Billing", Param: "billing", Type: "BillingUser", Struct: true, Ref: "BillingUser"}, field.Fields[0])
+	models := e.Models().All()
+	require.Len(t, models, 2)
+	assert.Equal(t, "BillingUser", models[0].Fields[0].Ref)
+	assert.Equal(t, "BillingUser", models[1].Ref)
+
+	// 限定名称与已有结构体同名时加长包路径后缀
+	for _, types := range [][]reflect.Type{
+		{billingUser, accountUser, reflect.TypeOf(BillingUser{})},
+		{reflect.TypeOf(BillingUser{}), accountUser, billingUser},
+	} {
+		e = NewExporter("", nil)
+		e.RegisterTypes(types...)
+		assert.Equal(t, "RegistryBillingUser", e.typeName(billingUser))
+		assert.Equal(t, "AccountUser", e.typeName(accountUser))
+		assert.Equal(t, "BillingUser", e.typeName(reflect.TypeOf(BillingUser{})))
+	}
+}
+
+// 方法引用的结构体与其定义
+func testRegistryProtocol() ([]*Method, []*Field) {
+	ref := func(name string) *Field {
+		return &Field{Type: name, Struct: true, Ref: name}
+	}
+	user := &Field{Name: "User", Type: "User", Struct: true, Ref: "User", Fields: []*Field{
+		{Name: "Name", Param: "name", Type: "string"},
+		{Name: "Home", Param: "home", Type: "Address", Struct: true, Ref: "Address"},
+		{Name: "Friends", Param: "friends", Array: true, Nested: true, Elem: ref("User")},
+	}}
+	address := &Field{Name: "Address", Type: "Address", Struct: true, Ref: "Address", StructDescription: "地址", Fields: []*Field{
+		{Name: "City", Param: "city", Type: "string", Validator: &Validator{Enums: []string{"sh", "bj"}}},
+	}}
+	methods := []*Method{{
+		Name:   "GetUser",
+		Path:   "/users/:id",
+		Method: "GET",
+		Output: user.Fork(),
+	}}
+	return methods, []*Field{user, address}
+}
+
+func TestRenderModels(t *testing.T) {
+	methods, models := testRegistryProtocol()
+	for _, c := range []struct {
+		lang string
+		want []string
+	}{
+		{"go", []string{"type Address struct", "City string", "// Address 地址", "Friends []*User"}},
+		{"axios", []string{"export interface Address", "city?: string", "// 地址", "home?: Address"}},
+	} {
+		files, err := NewSDK(methods, models).Files(DefaultMakers(), c.lang, "sdk")
+		require.NoError(t, err, c.lang)
+		var content string
+		for _, v := range files {
+			content += v.Content
+		}
+		for _, v := range c.want {
+			assert.Contains(t, content, v, c.lang)
+		}
+	}
+
+	// 未提供定义时引用的结构体仅有名称
+	data := MakeRenderData(Go, methods, nil, GoNamer, GoTyper)
+	require.Len(t, data.Structs, 2)
+	assert.Equal(t, "Address", data.Structs[1].Name)
+	assert.Empty(t, data.Structs[1].Fields)
+
+	data = MakeRenderData(Go, methods, models, GoNamer, GoTyper)
+	require.Len(t, data.Structs, 2)
+	assert.Equal(t, "User", data.Structs[0].Name)
+	assert.Equal(t, "Address", data.Structs[1].Name)
+	require.Len(t, data.Structs[1].Fields, 1)
+	assert.Equal(t, "City", data.Structs[1].Fields[0].Name)
+}
+
+func TestOpenAPIModels(t *testing.T) {
+	methods, models := testRegistryProtocol()
+	doc, err := NewOpenAPI(&ProtocolOutput{Methods: methods, Structs: models}).Document("")
+	require.NoError(t, err)
+	address := doc.Components.Schemas["Address"]
+	require.NotNil(t, address)
+	assert.Equal(t, "地址", address.Description)
+	assert.Equal(t, []string{"sh", "bj"}, address.Properties["city"].Enum)
+	user := doc.Components.Schemas["User"]
+	require.NotNil(t, user)
+	assert.Equal(t, &OpenAPISchema{Ref: "#/components/schemas/Address"}, user.Properties["home"])
+	assert.Equal(t, &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Ref: "#/components/schemas/User"}}, user.Properties["friends"])
+}
+
+func TestDiffModels(t *testing.T) {
+	methods, models := testRegistryProtocol()
+	from := &ProtocolOutput{Methods: methods, Structs: models}
+	_, changed := testRegistryProtocol()
+	changed[1].Fields[0].Validator = &Validator{Enums: []string{"sh", "bj", "gz"}}
+	changed[1].Fields = append(changed[1].Fields, &Field{Name: "Street", Param: "street", Type: "string"})
+	to := &ProtocolOutput{Methods: methods, Structs: changed}
+
+	d := DiffProtocol(from, to)
+	assert.True(t, d.Breaking)
+	assert.Equal(t, []*Change{
+		{Kind: ChangeEnumChanged, Breaking: true, Method: "GetUser", Route: "GET /users/:id", Field: "output.home.city", Old: "sh bj", New: "sh bj gz", Message: "枚举值变更"},
+		{Kind: ChangeFieldAdded, Method: "GetUser", Route: "GET /users/:id", Field: "output.home.street", New: "string", Message: "新增字段"},
+	}, d.Changes)
+
+	// 递归引用的结构体仅在首次出现时比较
+	assert.Empty(t, DiffProtocol(from, from).Changes)
+}
@@ -1,15 +1,20 @@
 package exporter
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/fatih/structs"
 	"github.com/flosch/pongo2/v5"
 	"strings"
+	"unicode"
 )
 
 type RenderData struct {
 	Packages []*RenderPackage
 	Methods  []*RenderMethod
 	Structs  []*RenderStruct
+	Codes    []*RenderCode
+	Envelope *Envelope // 响应包裹结构，错误响应按其字段解析
 }
 
 type RenderMethod struct {
@@ -20,6 +25,20 @@ type RenderMethod struct {
 	Description  string
 	Method       string
 	Path         string
+	PathParams   []*RenderPathParam
+	Envelope     string // 响应包裹中 data 的字段名，未包裹时为空
+	Binary       bool   // 输出为文件或二进制流，Go 返回 io.ReadCloser，TypeScript 返回 Blob
+	Multipart    bool   // 入参包含上传文件，以 multipart/form-data 提交
+	Stream       bool   // 事件流方法，以 SSE 订阅
+	EventType    string
+	EventStruct  bool
+	Locations    string // TypeScript 入参中 header、query、cookie 字段的位置表，如 {'tenant': ['header', 'X-Tenant']}
+}
+
+type RenderPathParam struct {
+	Placeholder string // 路径占位符，如 :shopId
+	Name        string // 入参字段名
+	Param       string // 入参字段参数名
 }
 
 type RenderStruct struct {
@@ -27,6 +46,7 @@ type RenderStruct struct {
 	Type        string
 	Description string
 	Fields      []*RenderField
+	Rules       string // TypeScript 校验规则表，由 TypeScript 生成器填充，无校验规则时为空
 }
 
 type RenderField struct {
@@ -36,6 +56,21 @@ type RenderField struct {
 	Description string
 	Required    bool
 	Label       string
+	In          string // 参数位置，同 Field.In
+	Key         string // 参数在所在位置的名称
+	Tag         string // Go 结构体字段标签，由 GoMaker 生成
+
+	Validator     *Validator
+	ElemValidator *Validator // 数组元素的校验器
+	NestedStruct  string     // 对象字段引用的结构体名称
+	ElemStruct    string     // 对象数组字段元素引用的结构体名称
+}
+
+type RenderCode struct {
+	Name    string // 错误码标识符，如 GoodDuplicate
+	Code    string
+	Status  int
+	Message string
 }
 
 type RenderPackage struct {
@@ -91,24 +126,100 @@ var EmptyFormatter Formatter = func(s string) (string, error) {
 	return s, nil
 }
 
-func MakeRenderData(lang string, methods []*Method, namer Namer, typer Typer) (data *RenderData) {
+// MakeRenderData 构造模板数据，方法中引用的结构体按 models 中的定义渲染
+func MakeRenderData(lang string, methods []*Method, models []*Field, namer Namer, typer Typer) (data *RenderData) {
 	data = new(RenderData)
+	registry := newRenderRegistry(models)
 	checker := newRenderFieldChecker()
+	codeChecker := newRenderFieldChecker()
 	renderPackages := new(RenderPackages)
 	for _, v := range methods {
 		data.Methods = append(data.Methods, makeRenderMethod(lang, v, namer, typer, renderPackages))
-		data.Structs = append(data.Structs, makeRenderStructs(lang, v, namer, typer, checker, renderPackages)...)
+		data.Structs = append(data.Structs, makeRenderStructs(lang, v, namer, typer, registry, checker, renderPackages)...)
+		data.Codes = append(data.Codes, makeRenderCodes(v, codeChecker)...)
+		if v.Envelope != nil && data.Envelope == nil {
+			data.Envelope = v.Envelope
+		}
 		data.Packages = renderPackages.list
 	}
 	return
 }
 
+// 收集方法声明的错误码，按 Code 去重
+func makeRenderCodes(method *Method, checker *renderFieldChecker) (renderCodes []*RenderCode) {
+	for _, v := range method.Codes {
+		if checker.Has(v.Code) {
+			continue
+		}
+		checker.Add(v.Code)
+		renderCodes = append(renderCodes, &RenderCode{
+			Name:    codeName(v.Code),
+			Code:    v.Code,
+			Status:  v.Status,
+			Message: v.Message,
+		})
+	}
+	return
+}
+
+// 将错误码转换为标识符，如 good.duplicate -> GoodDuplicate
+func codeName(code string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range code {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Code" + name
+	}
+	return name
+}
+
+// 检查错误码转换后的标识符是否冲突，如 not-found 与 NotFound 均转换为 NotFound
+func checkCodeNames(methods []*Method) error {
+	names := map[string]string{}
+	for _, m := range methods {
+		for _, v := range m.Codes {
+			name := codeName(v.Code)
+			if code, ok := names[name]; ok && code != v.Code {
+				return fmt.Errorf("error code '%s' and '%s' both map to identifier '%s'", code, v.Code, name)
+			}
+			names[name] = v.Code
+		}
+	}
+	return nil
+}
+
+// 将字符串转换为带引号的字面量，JSON 字符串同时是合法的 Go 与 TypeScript 字符串，输出时不再经 HTML 转义
+func quoteLiteral(s string) *pongo2.Value {
+	data, _ := json.Marshal(s)
+	return pongo2.AsSafeValue(string(data))
+}
+
+// 将文本转换为单行注释内容，避免换行截断注释，输出时不再经 HTML 转义
+func commentText(s string) *pongo2.Value {
+	return pongo2.AsSafeValue(strings.Join(strings.Fields(s), " "))
+}
+
 func makeRenderMethod(lang string, method *Method, namer Namer, typer Typer, renderPackages *RenderPackages) (renderMethod *RenderMethod) {
 	renderMethod = new(RenderMethod)
 	renderMethod.Name = namer(method.Name)
 	renderMethod.Description = method.Description
 	renderMethod.Method = method.Method
 	renderMethod.Path = method.Path
+	renderMethod.PathParams = makeRenderPathParams(method, namer)
+	if method.Envelope != nil {
+		renderMethod.Envelope = method.Envelope.Data
+	}
 	if method.Input != nil {
 		renderMethod.InputType = makeMethodIOName(lang, method.Input, typer, renderPackages)
 	}
@@ -116,9 +227,88 @@ func makeRenderMethod(lang string, method *Method, namer Namer, typer Typer, ren
 		renderMethod.OutputType = makeMethodIOName(lang, method.Output, typer, renderPackages)
 		renderMethod.OutputStruct = method.Output.Struct
 	}
+	renderMethod.Multipart = method.Multipart
+	if lang != Go {
+		renderMethod.Locations = makeRenderLocations(method)
+	}
+	if method.Event != nil {
+		renderMethod.Stream = true
+		renderMethod.EventType = makeMethodIOName(lang, method.Event, typer, renderPackages)
+		renderMethod.EventStruct = method.Event.Struct
+	}
+	if method.Binary {
+		renderMethod.Binary = true
+		renderMethod.OutputType = "Blob"
+		if lang == Go {
+			renderMethod.OutputType = "io.ReadCloser"
+		}
+	}
+	return
+}
+
+// 解析路径参数，并匹配入参中 uri 标签、参数名或字段名相同的字段
+func makeRenderPathParams(method *Method, namer Namer) (params []*RenderPathParam) {
+	if method.Input == nil {
+		return
+	}
+	for _, segment := range strings.Split(method.Path, "/") {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		key := segment[1:]
+		field := findPathField(method.Input.Fields, key)
+		if field == nil {
+			continue
+		}
+		param := field.Param
+		if param == "" {
+			param = field.Name
+		}
+		params = append(params, &RenderPathParam{
+			Placeholder: segment,
+			Name:        namer(field.Name),
+			Param:       param,
+		})
+	}
 	return
 }
 
+// 生成入参中 header、query、cookie 字段的位置表，路径参数已由 PathParams 处理
+func makeRenderLocations(method *Method) string {
+	if method.Input == nil || !method.Input.Struct {
+		return ""
+	}
+	var items []string
+	for _, v := range method.Input.Fields {
+		if v.In != InHeader && v.In != InQuery && v.In != InCookie {
+			continue
+		}
+		param := v.Param
+		if param == "" {
+			param = v.Name
+		}
+		items = append(items, fmt.Sprintf("'%s': ['%s', '%s']", param, v.In, v.Key))
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+}
+
+func findPathField(fields []*Field, key string) *Field {
+	for _, v := range fields {
+		if v.In == InPath && v.Key == key {
+			return v
+		}
+	}
+	for _, v := range fields {
+		if v.Param == key || strings.EqualFold(v.Name, key) {
+			return v
+		}
+	}
+	return nil
+}
+
 func makeMethodIOName(lang string, field *Field, typer Typer, renderPackages *RenderPackages) string {
 	if field.Array {
 		return parseNestedType(lang, field, typer, renderPackages)
@@ -138,6 +328,12 @@ func parseNestedType(lang string, field *Field, typer Typer, renderPackages *Ren
 }
 
 func getRenderFieldType(lang string, field *Field, renderPackages *RenderPackages) string {
+	if field.Type == TypeFile {
+		if lang == Go {
+			return "*File"
+		}
+		return "Blob"
+	}
 	_type := field.Type
 	if field.BasicType != nil {
 		if lang == Go {
@@ -162,30 +358,60 @@ func getRenderFieldType(lang string, field *Field, renderPackages *RenderPackage
 	return _type
 }
 
-func makeRenderStructs(lang string, method *Method, namer Namer, typer Typer,
+// 结构体定义表，键为 Ref
+type renderRegistry map[string]*Field
+
+func newRenderRegistry(models []*Field) renderRegistry {
+	registry := renderRegistry{}
+	for _, v := range models {
+		if v.Ref != "" {
+			registry[v.Ref] = v
+		}
+	}
+	return registry
+}
+
+// 返回引用的结构体定义，未携带成员的引用按 Ref 查找，定义缺失时返回引用自身
+func (p renderRegistry) resolve(field *Field) *Field {
+	if field.Ref == "" || len(field.Fields) > 0 {
+		return field
+	}
+	if def, ok := p[field.Ref]; ok {
+		return def
+	}
+	return field
+}
+
+func makeRenderStructs(lang string, method *Method, namer Namer, typer Typer, registry renderRegistry,
 	checker *renderFieldChecker, renderPackages *RenderPackages) (renderFields []*RenderStruct) {
 	if method.Input != nil && (method.Input.Struct || (method.Input.Array && method.Input.Nested)) {
-		toRenderStructs(lang, method.Input, namer, typer, checker, &renderFields, renderPackages)
+		toRenderStructs(lang, method.Input, namer, typer, registry, checker, &renderFields, renderPackages)
 	}
 	if method.Output != nil && (method.Output.Struct || (method.Output.Array && method.Output.Nested)) {
-		toRenderStructs(lang, method.Output, namer, typer, checker, &renderFields, renderPackages)
+		toRenderStructs(lang, method.Output, namer, typer, registry, checker, &renderFields, renderPackages)
+	}
+	if method.Event != nil && (method.Event.Struct || (method.Event.Array && method.Event.Nested)) {
+		toRenderStructs(lang, method.Event, namer, typer, registry, checker, &renderFields, renderPackages)
 	}
 	return
 }
 
-func toRenderStructs(lang string, field *Field, namer Namer, typer Typer, checker *renderFieldChecker,
+// 每个结构体仅渲染一次，引用的结构体按定义展开成员
+func toRenderStructs(lang string, field *Field, namer Namer, typer Typer, registry renderRegistry, checker *renderFieldChecker,
 	renderStructs *[]*RenderStruct, renderPackages *RenderPackages) {
 	if field.Array && field.Nested { // 处理嵌套数组对象
-		toRenderStructs(lang, field.Elem, namer, typer, checker, renderStructs, renderPackages)
+		toRenderStructs(lang, field.Elem, namer, typer, registry, checker, renderStructs, renderPackages)
 	} else if field.Struct { // 处理对象
+		field = registry.resolve(field)
 		renderStruct := new(RenderStruct)
 		name := namer(field.Type)
-		renderStruct.Name = name
-		renderStruct.Description = field.Description
-		if !checker.Has(name) {
-			checker.Add(name)
-			*renderStructs = append(*renderStructs, renderStruct)
+		if checker.Has(name) {
+			return
 		}
+		checker.Add(name)
+		renderStruct.Name = name
+		renderStruct.Description = field.StructDescription
+		*renderStructs = append(*renderStructs, renderStruct)
 		for _, v := range field.Fields {
 			renderField := new(RenderField)
 			renderField.Name = namer(v.Name)
@@ -193,14 +419,25 @@ func toRenderStructs(lang string, field *Field, namer Namer, typer Typer, checke
 			renderField.Type = parseNestedType(lang, v, typer, renderPackages)
 			renderField.Description = v.Description
 			renderField.Label = v.Label
+			renderField.In = v.In
+			renderField.Key = v.Key
 			if v.Validator != nil {
 				renderField.Required = v.Validator.Required
 			}
+			renderField.Validator = v.Validator
+			if v.Elem != nil {
+				renderField.ElemValidator = v.Elem.Validator
+			}
+			if v.Struct {
+				renderField.NestedStruct = namer(v.Type)
+			} else if v.Array && v.Elem != nil && v.Elem.Struct {
+				renderField.ElemStruct = namer(v.Elem.Type)
+			}
 			renderStruct.Fields = append(renderStruct.Fields, renderField)
 			if v.Struct {
-				toRenderStructs(lang, v, namer, typer, checker, renderStructs, renderPackages)
+				toRenderStructs(lang, v, namer, typer, registry, checker, renderStructs, renderPackages)
 			} else if v.Array && v.Nested {
-				toRenderStructs(lang, v.Elem, namer, typer, checker, renderStructs, renderPackages)
+				toRenderStructs(lang, v.Elem, namer, typer, registry, checker, renderStructs, renderPackages)
 			}
 		}
 	}
@@ -214,6 +451,8 @@ func Render(tpl string, data interface{}, formatter Formatter) (result string, e
 	}
 	ctx := structs.Map(data)
 	ctx["_trimPrefix"] = strings.TrimPrefix
+	ctx["_quote"] = quoteLiteral
+	ctx["_comment"] = commentText
 	result, err = _tpl.Execute(ctx)
 	if err != nil {
 		return
@@ -16,15 +16,67 @@ type File struct {
 	Content string `json:"content"`
 }
 
-func NewSDK(methods []*Method) *SDK {
-	return &SDK{methods: methods}
+// NewSDK 构造 SDK，models 为方法中引用的结构体定义
+func NewSDK(methods []*Method, models []*Field) *SDK {
+	return &SDK{methods: methods, models: models}
 }
 
 type SDK struct {
 	methods []*Method
+	models  []*Field
+}
+
+// NewProtocolSDK 由接口描述协议构造 SDK，协议可来自离线保存的 /protocol 输出
+//
+// 协议中的字段不携带基础类型映射，按类型名从协议的 basics 中恢复
+func NewProtocolSDK(protocol *ProtocolOutput) *SDK {
+	basics := map[string]*BasicType{}
+	for _, v := range protocol.Basics {
+		basics[v.Type] = v
+	}
+	var methods []*Method
+	for _, v := range protocol.Methods {
+		m := v.Fork()
+		for _, field := range []*Field{m.Input, m.Output, m.Event} {
+			restoreBasicType(field, basics)
+		}
+		for _, code := range m.Codes {
+			restoreBasicType(code.Details, basics)
+		}
+		methods = append(methods, m)
+	}
+	var models []*Field
+	for _, v := range protocol.Structs {
+		def := v.Fork()
+		restoreBasicType(def, basics)
+		models = append(models, def)
+	}
+	return NewSDK(methods, models)
+}
+
+func restoreBasicType(field *Field, basics map[string]*BasicType) {
+	if field == nil {
+		return
+	}
+	if field.BasicType == nil && !field.Struct {
+		field.BasicType = basics[field.Type]
+	}
+	for _, v := range field.Fields {
+		restoreBasicType(v, basics)
+	}
+	restoreBasicType(field.Elem, basics)
 }
 
 func (p SDK) Make(makers map[string]Maker, lang, pkg string) ([]byte, error) {
+	files, err := p.Files(makers, lang, pkg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(files)
+}
+
+// Files 使用 lang 对应的生成器生成 SDK 文件
+func (p SDK) Files(makers map[string]Maker, lang, pkg string) ([]*File, error) {
 	maker, ok := makers[lang]
 	if !ok {
 		return nil, fmt.Errorf("target '%s' maker not found", lang)
@@ -33,9 +85,12 @@ func (p SDK) Make(makers map[string]Maker, lang, pkg string) ([]byte, error) {
 	for _, v := range p.methods {
 		methods = append(methods, v.Fork())
 	}
-	files, err := maker.Make(pkg,methods)
-	if err != nil {
+	if err := checkCodeNames(methods); err != nil {
 		return nil, err
 	}
-	return json.Marshal(files)
+	var models []*Field
+	for _, v := range p.models {
+		models = append(models, v.Fork())
+	}
+	return maker.Make(pkg,methods,models)
 }
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderGoStream(t *testing.T) {
+	methods := []*Method{{
+		Name:   "WatchOrder",
+		Path:   "/WatchOrder",
+		Method: "GET",
+		Event:  &Field{Name: "Order", Type: "Order", Struct: true, Fields: []*Field{{Name: "Id", Param: "id", Type: "int64"}}},
+	}}
+	content := testSDKContent(t, "go", methods)
+	for _, v := range []string{
+		"WatchOrder(ctx context.Context) (out <-chan *Order, errc <-chan error, err error)",
+		"ec := make(chan error, 1)",
+		"defer close(ec)",
+		"ec <- readEvents(body, func(data []byte) error {",
+		"out, errc = ch, ec",
+	} {
+		assert.Contains(t, content, v)
+	}
+	assert.NotContains(t, content, "_ = readEvents(")
+}
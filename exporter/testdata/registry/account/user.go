@@ -0,0 +1,5 @@
+package account
+
+type User struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,5 @@
+package billing
+
+type User struct {
+	Id int64 `json:"id"`
+}
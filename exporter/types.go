@@ -74,13 +74,35 @@ type Env struct {
 }
 
 type Method struct {
-	Name        string `json:"name,omitempty"`
-	Path        string `json:"path,omitempty"`
-	Method      string `json:"method,omitempty"`
-	Description string `json:"description,omitempty"`
-	Middlewares string `json:"middlewares,omitempty"`
-	Input       *Field `json:"input,omitempty"`
-	Output      *Field `json:"output,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Middlewares string    `json:"middlewares,omitempty"`
+	Input       *Field    `json:"input,omitempty"`
+	Output      *Field    `json:"output,omitempty"`
+	Codes       []*Code   `json:"codes,omitempty"`
+	Envelope    *Envelope `json:"envelope,omitempty"`
+	Binary      bool      `json:"binary,omitempty"`    // 输出为文件或二进制流
+	Multipart   bool      `json:"multipart,omitempty"` // 入参包含上传文件，以 multipart/form-data 提交
+	Event       *Field    `json:"event,omitempty"`     // 事件流的事件类型，以 SSE 推送
+}
+
+// Envelope 响应包裹结构，字段值为包裹对象中对应的字段名
+type Envelope struct {
+	Code    string `json:"code"`
+	Data    string `json:"data"`
+	Message string `json:"message"`
+	TraceId string `json:"traceId,omitempty"`
+	Success string `json:"success"` // 成功时 code 的取值
+}
+
+// Code Action 声明的错误码
+type Code struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Details *Field `json:"details,omitempty"` // 错误详情结构，如入参校验失败时的字段错误列表
 }
 
 func (p Method) Fork() *Method {
@@ -90,35 +112,94 @@ func (p Method) Fork() *Method {
 	n.Method = p.Method
 	n.Description = p.Description
 	n.Middlewares = p.Middlewares
+	n.Binary = p.Binary
+	n.Multipart = p.Multipart
+	if p.Event != nil {
+		n.Event = p.Event.Fork()
+	}
 	if p.Input != nil {
 		n.Input = p.Input.Fork()
 	}
 	if p.Output != nil {
 		n.Output = p.Output.Fork()
 	}
+	for _, v := range p.Codes {
+		c := *v
+		if v.Details != nil {
+			c.Details = v.Details.Fork()
+		}
+		n.Codes = append(n.Codes, &c)
+	}
+	if p.Envelope != nil {
+		e := *p.Envelope
+		n.Envelope = &e
+	}
 	return n
 }
 
+// Route 已注册的路由
+type Route struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Handler    string `json:"handler"`
+	Location   string `json:"location,omitempty"`
+	Permission string `json:"permission"`
+	Type       string `json:"type,omitempty"`
+	Resource   string `json:"resource,omitempty"` // 资源名称模板
+}
+
+// Permission 由 Action 导出的权限描述
+type Permission struct {
+	Name        string                `json:"name"`               // 权限名称，形如 分组:方法名
+	Action      string                `json:"action"`             // 方法名
+	Group       string                `json:"group,omitempty"`    // 分组名称
+	Type        string                `json:"type,omitempty"`     // Action 类型，如 read、write、list
+	Resource    string                `json:"resource,omitempty"` // 资源名称模板，如 shop/$shopId[/cate/$cateId]，[] 内为可选资源
+	Description string                `json:"description,omitempty"`
+	Method      string                `json:"method,omitempty"`
+	Path        string                `json:"path,omitempty"`
+	Resources   []*PermissionResource `json:"resources,omitempty"`
+}
+
+type PermissionResource struct {
+	Name        string      `json:"name"`
+	Template    string      `json:"template"` // 资源名称模板，如 shop/$shopId
+	Description string      `json:"description,omitempty"`
+	Optional    bool        `json:"optional,omitempty"`
+	Ident       []*Variable `json:"ident,omitempty"` // 资源标识变量
+	Scope       []*Variable `json:"scope,omitempty"` // 资源范围变量
+}
+
+type Variable struct {
+	Var         string `json:"var"`
+	Description string `json:"description,omitempty"`
+}
+
 type Struct struct {
 	Name   string   `json:"name"`
 	Fields []*Field `json:"fields"`
 }
 
 type Field struct {
-	Name        string     `json:"name,omitempty"`
-	Param       string     `json:"param,omitempty"`
-	Label       string     `json:"label,omitempty"`
-	Type        string     `json:"type,omitempty"`
-	Description string     `json:"description,omitempty"`
-	Array       bool       `json:"array,omitempty"`
-	Struct      bool       `json:"struct,omitempty"`
-	Nested      bool       `json:"nested,omitempty"`
-	Origin      string     `json:"origin,omitempty"`    // 原始类型
-	Fields      []*Field   `json:"fields,omitempty"`    // 描述 Struct 成员变量
-	Elem        *Field     `json:"elem,omitempty"`      // 描述 Slice/Array 子元素
-	Validator   *Validator `json:"validator,omitempty"` // 定义校验器
-	Form        string     `json:"form,omitempty"`      // 定义表单组件
-	BasicType   *BasicType `json:"-"`
+	Name              string     `json:"name,omitempty"`
+	Param             string     `json:"param,omitempty"`
+	Label             string     `json:"label,omitempty"`
+	Type              string     `json:"type,omitempty"`
+	Description       string     `json:"description,omitempty"`
+	Array             bool       `json:"array,omitempty"`
+	Struct            bool       `json:"struct,omitempty"`
+	Nested            bool       `json:"nested,omitempty"`
+	Origin            string     `json:"origin,omitempty"`            // 原始类型
+	Fields            []*Field   `json:"fields,omitempty"`            // 描述 Struct 成员变量
+	Elem              *Field     `json:"elem,omitempty"`              // 描述 Slice/Array 子元素
+	Validator         *Validator `json:"validator,omitempty"`         // 定义校验器
+	Form              string     `json:"form,omitempty"`              // 定义表单组件
+	In                string     `json:"in,omitempty"`                // 参数位置：path、query、header、cookie、formData，为空时随请求体或查询参数传递
+	Key               string     `json:"key,omitempty"`               // 参数在所在位置的名称
+	StructDescription string     `json:"structDescription,omitempty"` // 结构体类型的描述，Description 为字段自身的描述
+	Ref               string     `json:"ref,omitempty"`               // 具名结构体的定义名称，对应 Structs 中的定义；递归出现时不展开成员
+	Package           string     `json:"package,omitempty"`           // 结构体定义所在的包路径，仅 Structs 中的定义携带
+	BasicType         *BasicType `json:"-"`
 }
 
 func (p Field) Fork() *Field {
@@ -140,10 +221,38 @@ func (p Field) Fork() *Field {
 	}
 	n.Validator = p.Validator
 	n.Form = p.Form
+	n.In = p.In
+	n.Key = p.Key
 	n.BasicType = p.BasicType
+	n.StructDescription = p.StructDescription
+	n.Ref = p.Ref
+	n.Package = p.Package
 	return n
 }
 
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InCookie = "cookie"
+	InForm   = "formData" // multipart 表单，文件字段所在位置
+)
+
+const (
+	TypeFile = "file" // 上传文件，对应 *multipart.FileHeader
+	FormFile = "file" // 文件选择组件
+)
+
+// HasFile 检查直接成员中是否包含上传文件字段
+func (p Field) HasFile() bool {
+	for _, v := range p.Fields {
+		if v.Type == TypeFile || (v.Elem != nil && v.Elem.Type == TypeFile) {
+			return true
+		}
+	}
+	return false
+}
+
 type Fields struct {
 	list    []*Field
 	mapping map[string]bool
@@ -164,13 +273,27 @@ func (p Fields) All() []*Field {
 	return p.list
 }
 
+// Validator 字段校验规则，由 binding 标签中的 go-playground/validator 规则转换而来
+//
+// Min、Max、Gt、Lt、Len 对字符串、数组比较长度，对数值比较取值
 type Validator struct {
 	Required bool     `json:"required,omitempty"`
-	Max      *uint64  `json:"max,omitempty"`
-	Min      *int64   `json:"min,omitempty"`
-	Enums    []string `json:"enums,omitempty"`
+	Max      *float64 `json:"max,omitempty"` // max、lte
+	Min      *float64 `json:"min,omitempty"` // min、gte
+	Gt       *float64 `json:"gt,omitempty"`
+	Lt       *float64 `json:"lt,omitempty"`
+	Len      *float64 `json:"len,omitempty"`
+	Enums    []string `json:"enums,omitempty"`   // oneof
+	Format   string   `json:"format,omitempty"`  // email、url
+	Pattern  string   `json:"pattern,omitempty"` // regex
+	dive     *Validator
 }
 
+const (
+	FormatEmail = "email"
+	FormatUrl   = "url"
+)
+
 type Component struct {
 	Name string
 }
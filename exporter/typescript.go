@@ -1,5 +1,334 @@
 package exporter
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 const tsClassTpl = `
 
 `
+
+// 将入参转换为 multipart 表单，由各 TypeScript SDK 生成器共用
+const tsFormDataTpl = `
+// 将入参转换为 FormData，文件以文件域提交，对象以 JSON 字符串提交
+export function toFormData(params: any): FormData {
+    const data = new FormData();
+    Object.keys(params || {}).forEach(key => {
+        const value = params[key];
+        if (value === undefined || value === null) {
+            return;
+        }
+        (Array.isArray(value) ? value : [value]).forEach(v => {
+            if (v instanceof Blob) {
+                data.append(key, v);
+            } else if (typeof v === 'object') {
+                data.append(key, JSON.stringify(v));
+            } else {
+                data.append(key, String(v));
+            }
+        });
+    });
+    return data;
+}
+`
+
+// 按字段位置拆分入参，由各 TypeScript SDK 生成器共用
+const tsLocationTpl = `
+export interface Located {
+    headers: { [key: string]: string };
+    query: { [key: string]: any };
+    rest: any;
+}
+
+// 按位置表拆分入参：header、query 字段以声明的名称返回，cookie 字段由浏览器携带不再提交，其余字段随请求体或查询参数提交
+export function splitParams(params: any, locations: { [param: string]: string[] }): Located {
+    const located: Located = {headers: {}, query: {}, rest: {}};
+    Object.keys(params || {}).forEach(key => {
+        const value = params[key];
+        const location = locations[key];
+        if (!location) {
+            located.rest[key] = value;
+            return;
+        }
+        if (value === undefined || value === null) {
+            return;
+        }
+        if (location[0] === 'header') {
+            located.headers[location[1]] = String(value);
+        } else if (location[0] === 'query') {
+            located.query[location[1]] = value;
+        }
+    });
+    return located;
+}
+
+// 按路径段替换路径参数，避免 :shop 误替换 :shopId 的前缀
+export function fillPath(path: string, values: { [placeholder: string]: any }): string {
+    return path.split('/').map(segment => segment in values ? encodeURIComponent(String(values[segment])) : segment).join('/');
+}
+`
+
+// 以 EventSource 订阅事件流，由各 TypeScript SDK 生成器共用
+const tsEventTpl = `
+export interface EventHandlers<T> {
+    next: (event: T) => void;
+    error?: (err: any) => void;
+    complete?: () => void;
+}
+
+// 以 EventSource 订阅事件流，调用返回值的 close 方法取消订阅
+export function subscribe<T>(url: string, params: any, handlers: EventHandlers<T>, init?: EventSourceInit): EventSource {
+    const query = new URLSearchParams();
+    Object.keys(params || {}).forEach(key => {
+        const value = params[key];
+        if (value === undefined || value === null) {
+            return;
+        }
+        (Array.isArray(value) ? value : [value]).forEach(v => {
+            query.append(key, typeof v === 'object' ? JSON.stringify(v) : String(v));
+        });
+    });
+    if (query.toString()) {
+        url += (url.indexOf('?') < 0 ? '?' : '&') + query.toString();
+    }
+    const source = new EventSource(url, init);
+    source.onmessage = (e: MessageEvent) => handlers.next(JSON.parse(e.data));
+    source.addEventListener('end', () => {
+        source.close();
+        if (handlers.complete) {
+            handlers.complete();
+        }
+    });
+    source.addEventListener('error', (e: any) => {
+        // 服务端 error 事件携带 data，此时关闭连接；连接中断时由 EventSource 自动重连
+        if (e.data !== undefined) {
+            source.close();
+            if (handlers.error) {
+                handlers.error(new Error(e.data));
+            }
+        } else if (source.readyState === EventSource.CLOSED && handlers.error) {
+            handlers.error(e);
+        }
+    });
+    return source;
+}
+`
+
+// 错误码枚举与错误类，由各 TypeScript SDK 生成器共用
+const tsErrorsTpl = `
+export enum ErrorCode {
+{% for code in Codes %}    {{ code.Name }} = {{ _quote(code.Code) }}, {% if code.Message %}// {{ _comment(code.Message) }}{% endif %}
+{% endfor %}}
+
+export const ErrorMessages: { [code: string]: string } = {
+{% for code in Codes %}    {{ _quote(code.Code) }}: {{ _quote(code.Message) }},
+{% endfor %}};
+
+export class APIError extends Error {
+    status: number;
+    code: string;
+    details?: any;
+    fields: ValidationError[]; // 入参校验失败（InvalidParams）时的字段错误，可按 field 映射到表单项
+
+    constructor(status: number, code: string, message: string, details?: any) {
+        super(message);
+        this.status = status;
+        this.code = code;
+        this.details = details;
+        this.fields = code === 'InvalidParams' && Array.isArray(details) ? details : [];
+    }
+}
+
+// 将错误响应转换为 APIError
+export function toAPIError(status: number, body: any): APIError {
+{% if Envelope %}    // 从响应包裹中解析错误码与错误信息
+    if (body && typeof body === 'object' && body[{{ _quote(Envelope.Code) }}]) {
+        const code = body[{{ _quote(Envelope.Code) }}];
+        return new APIError(status, code, body[{{ _quote(Envelope.Message) }}] || ErrorMessages[code] || '', body[{{ _quote(Envelope.Data) }}]);
+    }
+{% else %}    if (body && typeof body === 'object' && body.code) {
+        return new APIError(status, body.code, body.message || ErrorMessages[body.code] || '', body.details);
+    }
+{% endif %}
+    return new APIError(status, '', typeof body === 'string' ? body : '');
+}
+`
+
+// 生成结构体的校验函数，prefix 为类型所在的命名空间，如 API.
+func tsValidateTpl(prefix string) string {
+	return `
+export interface ValidationError {
+    field: string;
+    rule: string;
+    param?: any;
+    message?: string;
+}
+
+export interface Rule {
+    required?: boolean;
+    min?: number;
+    max?: number;
+    gt?: number;
+    lt?: number;
+    len?: number;
+    enums?: string[];
+    format?: string;
+    pattern?: string;
+    elem?: Rule;
+    nested?: (value: any, prefix: string) => ValidationError[];
+}
+
+// 按规则校验入参，字段为空时仅检查 required；字符串、数组比较长度，数值比较取值
+export function checkRules(params: any, rules: { [field: string]: Rule }, prefix: string = ''): ValidationError[] {
+    const errors: ValidationError[] = [];
+    Object.keys(rules).forEach(key => checkRule(params ? params[key] : undefined, rules[key], prefix + key, errors));
+    return errors;
+}
+
+function checkRule(value: any, rule: Rule, field: string, errors: ValidationError[]) {
+    if (value === undefined || value === null || value === '') {
+        if (rule.required) {
+            errors.push({field: field, rule: 'required'});
+        }
+        return;
+    }
+    let size: number = value;
+    if (typeof value === 'string') {
+        size = value.replace(/[\uD800-\uDBFF][\uDC00-\uDFFF]/g, '_').length;
+    } else if (Array.isArray(value)) {
+        size = value.length;
+    }
+    const fail = (name: string, param?: any) => errors.push({field: field, rule: name, param: param});
+    if (rule.min !== undefined && size < rule.min) {
+        fail('min', rule.min);
+    }
+    if (rule.max !== undefined && size > rule.max) {
+        fail('max', rule.max);
+    }
+    if (rule.gt !== undefined && size <= rule.gt) {
+        fail('gt', rule.gt);
+    }
+    if (rule.lt !== undefined && size >= rule.lt) {
+        fail('lt', rule.lt);
+    }
+    if (rule.len !== undefined && size !== rule.len) {
+        fail('len', rule.len);
+    }
+    if (rule.enums && rule.enums.indexOf(String(value)) < 0) {
+        fail('oneof', rule.enums);
+    }
+    if (rule.format === 'email' && !/^[^\s@]+@[^\s@]+\.[^\s@]+$/.test(String(value))) {
+        fail('email');
+    }
+    if (rule.format === 'url' && !/^[a-zA-Z][a-zA-Z\d+\-.]*:\S+$/.test(String(value))) {
+        fail('url');
+    }
+    if (rule.pattern !== undefined && !new RegExp(rule.pattern).test(String(value))) {
+        fail('regex', rule.pattern);
+    }
+    if (rule.elem && Array.isArray(value)) {
+        value.forEach((v, i) => checkRule(v, rule.elem as Rule, field + '[' + i + ']', errors));
+    }
+    if (rule.nested && typeof value === 'object' && !Array.isArray(value)) {
+        errors.push(...rule.nested(value, field + '.'));
+    }
+}
+{% for struct in Structs %}{% if struct.Rules %}
+export function validate{{ struct.Name }}(params: ` + prefix + `{{ struct.Name }}, prefix: string = ''): ValidationError[] {
+    return checkRules(params, {{ struct.Rules|safe }}, prefix);
+}
+{% endif %}{% endfor %}
+`
+}
+
+// 生成各结构体的 TypeScript 校验规则表，引用的结构体存在校验规则时以 nested 递归校验
+func makeTsRules(data *RenderData) {
+	validated := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, v := range data.Structs {
+			if validated[v.Name] {
+				continue
+			}
+			for _, vv := range v.Fields {
+				if tsFieldRule(vv, validated) != "" {
+					validated[v.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, v := range data.Structs {
+		if !validated[v.Name] {
+			continue
+		}
+		var items []string
+		for _, vv := range v.Fields {
+			if rule := tsFieldRule(vv, validated); rule != "" {
+				items = append(items, fmt.Sprintf("%s: %s", tsString(vv.Param), rule))
+			}
+		}
+		v.Rules = fmt.Sprintf("{%s}", strings.Join(items, ", "))
+	}
+}
+
+func tsFieldRule(field *RenderField, validated map[string]bool) string {
+	items := tsValidatorItems(field.Validator)
+	if field.NestedStruct != "" && validated[field.NestedStruct] {
+		items = append(items, "nested: validate"+field.NestedStruct)
+	}
+	if field.ElemValidator != nil {
+		elem := tsValidatorItems(field.ElemValidator)
+		if field.ElemStruct != "" && validated[field.ElemStruct] {
+			elem = append(elem, "nested: validate"+field.ElemStruct)
+		}
+		if len(elem) > 0 {
+			items = append(items, fmt.Sprintf("elem: {%s}", strings.Join(elem, ", ")))
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+}
+
+func tsValidatorItems(v *Validator) (items []string) {
+	if v == nil {
+		return
+	}
+	if v.Required {
+		items = append(items, "required: true")
+	}
+	for _, n := range []struct {
+		name  string
+		value *float64
+	}{{"min", v.Min}, {"max", v.Max}, {"gt", v.Gt}, {"lt", v.Lt}, {"len", v.Len}} {
+		if n.value != nil {
+			items = append(items, fmt.Sprintf("%s: %s", n.name, strconv.FormatFloat(*n.value, 'f', -1, 64)))
+		}
+	}
+	if len(v.Enums) > 0 {
+		var enums []string
+		for _, e := range v.Enums {
+			enums = append(enums, tsString(e))
+		}
+		items = append(items, fmt.Sprintf("enums: [%s]", strings.Join(enums, ", ")))
+	}
+	if v.Format != "" {
+		items = append(items, "format: "+tsString(v.Format))
+	}
+	if v.Pattern != "" {
+		items = append(items, "pattern: "+tsString(v.Pattern))
+	}
+	return
+}
+
+// 转换为 TypeScript 字符串字面量
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
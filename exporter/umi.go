@@ -6,8 +6,8 @@ type UmiMaker struct {
 func (u UmiMaker) Lang() string {
 	return Ts
 }
-func (u UmiMaker) Make(pkg string, methods []*Method) (files []*File, err error) {
-	data := MakeRenderData(u.Lang(), methods, EmptyNamer, TsTyper)
+func (u UmiMaker) Make(pkg string, methods []*Method, models []*Field) (files []*File, err error) {
+	data := MakeRenderData(u.Lang(), methods, models, EmptyNamer, TsTyper)
 	for _, v := range data.Structs {
 		for _, vv := range v.Fields {
 			if vv.Param == "" {
@@ -15,9 +15,10 @@ func (u UmiMaker) Make(pkg string, methods []*Method) (files []*File, err error)
 			}
 		}
 	}
+	makeTsRules(data)
 	apiFile := new(File)
 	apiFile.Name = "api.make.ts"
-	apiFile.Content, err = Render(umiServiceTpl, data, EmptyFormatter)
+	apiFile.Content, err = Render(umiServiceTpl+tsFormDataTpl+tsLocationTpl+tsEventTpl+tsErrorsTpl+tsValidateTpl("API."), data, EmptyFormatter)
 	if err != nil {
 		return
 	}
@@ -37,29 +38,42 @@ const umiServiceTpl = `
 import {request} from 'umi';
 
 {% for method in Methods %}
-{% if method.Description %}// {{ method.Description }}{% endif %}
+{% if method.Description %}// {{ _comment(method.Description) }}{% endif %}{% if method.Stream %}
+export function {{ method.Name }}({% if method.InputType !='' %}params: API.{{ method.InputType }}, {% endif %}handlers: EventHandlers<API.{{ method.EventType }}>, init?: EventSourceInit): EventSource {
+	return subscribe<API.{{ method.EventType }}>({% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, {% if method.InputType !='' %}params{% else %}null{% endif %}, handlers, init);
+}{% else %}
 export async function {{ method.Name }}({% if method.InputType !='' %}params: API.{{ method.InputType }}, {% endif %}options?: { [key: string]: any }) {
-	{% if  method.Method == 'GET' or method.Method == 'DELETE' %}return request<{% if method.OutputType !='' %}API.{{ method.OutputType }}{% else %}null{% endif %}>('{{ method.Path }}', {
-		method: '{{ method.Method }}',{% if method.InputType !='' %}
-		params: params,{%endif%}
+	{% if method.Locations %}const located = splitParams(params, {{ method.Locations|safe }});
+	{% endif %}{% if  method.Method == 'GET' or method.Method == 'DELETE' %}return request<{% if method.Binary %}Blob{% elif method.OutputType !='' %}API.{{ method.OutputType }}{% else %}null{% endif %}>({% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, {
+		method: '{{ method.Method }}',{% if method.Locations %}
+		headers: located.headers,
+		params: {...located.rest, ...located.query},{% elif method.InputType !='' %}
+		params: params,{%endif%}{% if method.Binary %}
+		responseType: 'blob',{% endif %}
 		...(options || {}),
-	}){% else %}return request<{% if method.OutputType !='' %}API.{{ method.OutputType }}{% else %}null{% endif %}>('{{ method.Path }}', {
-		method: '{{ method.Method }}',
+	}){% if method.Envelope %}.then((res: any) => res && res['{{ method.Envelope }}']){% endif %}.catch((e: any) => Promise.reject(e && e.response ? toAPIError(e.response.status, e.data) : e));{% else %}return request<{% if method.Binary %}Blob{% elif method.OutputType !='' %}API.{{ method.OutputType }}{% else %}null{% endif %}>({% if method.PathParams %}fillPath('{{ method.Path }}', { {% for param in method.PathParams %}'{{ param.Placeholder }}': params.{{ param.Param }}, {% endfor %}}){% else %}'{{ method.Path }}'{% endif %}, {
+		method: '{{ method.Method }}',{% if method.Locations %}
+		params: located.query,{% endif %}{% if method.Multipart %}{% if method.Locations %}
+		headers: located.headers,{% endif %}
+		data: toFormData({% if method.Locations %}located.rest{% else %}params{% endif %}),{% else %}
 		headers: {
-			'Content-Type': 'application/json',
+			'Content-Type': 'application/json',{% if method.Locations %}
+			...located.headers,{% endif %}
 		},
-		{% if method.InputType !='' %}data: params,{% endif %}
+		{% if method.Locations %}data: located.rest,{% elif method.InputType !='' %}data: params,{% endif %}{% endif %}{% if method.Binary %}
+		responseType: 'blob',{% endif %}
 		...(options || {}),
-	}){% endif %}
-}
+	}){% if method.Envelope %}.then((res: any) => res && res['{{ method.Envelope }}']){% endif %}.catch((e: any) => Promise.reject(e && e.response ? toAPIError(e.response.status, e.data) : e));{% endif %}
+}{% endif %}
 {% endfor %}
 `
 
 const umiTypingDTpl = `
 declare namespace API{
 	{% for struct in Structs %}
-	export interface {{ struct.Name }} {
-	{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{field.Label}} {{field.Description}}{% endif %}
+	{% if struct.Description %}// {{ _comment(struct.Description) }}
+	{% endif %}export interface {{ struct.Name }} {
+	{% for field in struct.Fields %}    {{field.Param}}?: {{field.Type}}, {% if field.Label or field.Description %}// {{ _comment(field.Label) }} {{ _comment(field.Description) }}{% endif %}
 	{% endfor %}}
 	{% endfor %}
 }
@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"github.com/utilslab/iam/binding"
+	"strconv"
+	"strings"
+)
+
+// ParseValidator 将 go-playground/validator 规则转换为校验器，dive 之后的规则作用于数组元素
+//
+// 含 | 的或规则、跨字段规则等无法在客户端表达的规则将被忽略，无可导出规则时返回 nil
+func ParseValidator(tag string) *Validator {
+	if tag == "" || tag == "-" {
+		return nil
+	}
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		if rule != "dive" {
+			continue
+		}
+		v := parseRules(rules[:i])
+		if v == nil {
+			v = new(Validator)
+		}
+		v.dive = ParseValidator(strings.Join(rules[i+1:], ","))
+		if v.dive == nil {
+			v.dive = new(Validator)
+		}
+		return v
+	}
+	return parseRules(rules)
+}
+
+func parseRules(rules []string) (validator *Validator) {
+	v := new(Validator)
+	var ok bool
+	for _, rule := range rules {
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		switch name {
+		case "required":
+			v.Required = true
+		case "min", "gte":
+			v.Min = parseNumber(param)
+		case "max", "lte":
+			v.Max = parseNumber(param)
+		case "gt":
+			v.Gt = parseNumber(param)
+		case "lt":
+			v.Lt = parseNumber(param)
+		case "len":
+			v.Len = parseNumber(param)
+		case "oneof":
+			v.Enums = parseEnums(param)
+		case "email":
+			v.Format = FormatEmail
+		case "url", "uri":
+			v.Format = FormatUrl
+		case "regex":
+			v.Pattern = binding.RegexParam(param)
+		default:
+			continue
+		}
+		ok = true
+	}
+	if ok {
+		validator = v
+	}
+	return
+}
+
+func parseNumber(s string) *float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// 解析 oneof 参数，以空格分隔，含空格的取值以单引号包裹，如 oneof='red green' blue
+func parseEnums(s string) (enums []string) {
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '\'' {
+			if i := strings.IndexByte(s[1:], '\''); i >= 0 {
+				enums = append(enums, s[1:i+1])
+				s = s[i+2:]
+				continue
+			}
+		}
+		i := strings.IndexByte(s, ' ')
+		if i < 0 {
+			i = len(s)
+		}
+		enums = append(enums, s[:i])
+		s = s[i:]
+	}
+	return
+}
+
+// 数组元素的校验器，未声明 dive 时为 nil
+func (p *Validator) elem() *Validator {
+	if p == nil {
+		return nil
+	}
+	return p.dive
+}
@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNumber(v float64) *float64 {
+	return &v
+}
+
+func TestParseValidator(t *testing.T) {
+	for _, c := range []struct {
+		tag  string
+		want *Validator
+	}{
+		{"", nil},
+		{"-", nil},
+		{"omitempty", nil},
+		{"eqfield=Password", nil},
+		{"required", &Validator{Required: true}},
+		{"required,min=2,max=20", &Validator{Required: true, Min: testNumber(2), Max: testNumber(20)}},
+		{"gte=1,lte=9,gt=0,lt=10,len=4", &Validator{Min: testNumber(1), Max: testNumber(9), Gt: testNumber(0), Lt: testNumber(10), Len: testNumber(4)}},
+		{"omitempty,oneof=red 'light blue' green", &Validator{Enums: []string{"red", "light blue", "green"}}},
+		{"email", &Validator{Format: FormatEmail}},
+		{"url", &Validator{Format: FormatUrl}},
+		{"uri", &Validator{Format: FormatUrl}},
+		{"rgb|rgba,required", &Validator{Required: true}},
+		{"regex=^[a-z]{10x2C3}$", &Validator{Pattern: "^[a-z]{1,3}$"}},
+		{"regex=^(a0x7Cb)$", &Validator{Pattern: "^(a|b)$"}},
+		{"min=abc", &Validator{}},
+	} {
+		assert.Equal(t, c.want, ParseValidator(c.tag), c.tag)
+	}
+}
+
+func TestParseValidatorDive(t *testing.T) {
+	v := ParseValidator("max=5,dive,min=1")
+	assert.Equal(t, testNumber(5), v.Max)
+	assert.Equal(t, &Validator{Min: testNumber(1)}, v.elem())
+
+	v = ParseValidator("dive")
+	assert.Equal(t, &Validator{}, v.elem())
+	assert.Nil(t, ParseValidator("required").elem())
+	assert.Nil(t, (*Validator)(nil).elem())
+}
+
+func TestGetFieldValidator(t *testing.T) {
+	var s struct {
+		Name   string `binding:"required,max=20"`
+		Legacy string `validator:"required"`
+		Both   string `binding:"max=3" validator:"required"`
+		Empty  string `validator:"-"`
+		None   string
+	}
+	st := reflect.TypeOf(s)
+	for _, c := range []struct {
+		field string
+		want  *Validator
+	}{
+		{"Name", &Validator{Required: true, Max: testNumber(20)}},
+		{"Legacy", nil},
+		{"Both", &Validator{Max: testNumber(3)}},
+		{"Empty", nil},
+		{"None", nil},
+	} {
+		f, _ := st.FieldByName(c.field)
+		assert.Equal(t, c.want, Exporter{}.getFieldValidator(f), c.field)
+	}
+}
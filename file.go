@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// File 文件下载输出，以附件形式分块输出 Reader 的内容
+type File struct {
+	Name        string    // 下载文件名
+	ContentType string    // 未设置时为 application/octet-stream
+	Reader      io.Reader // 输出完成后，如实现 io.Closer 则关闭
+}
+
+// Stream 二进制流输出，以 application/octet-stream 分块输出，输出完成后如实现 io.Closer 则关闭
+type Stream io.Reader
+
+var (
+	fileType    = reflect.TypeOf(File{})
+	streamType  = reflect.TypeOf((*Stream)(nil)).Elem()
+	binaryTypes = []reflect.Type{fileType, reflect.PtrTo(fileType), streamType}
+)
+
+// 检查输出是否为文件或二进制流
+func isBinary(t reflect.Type) bool {
+	for _, v := range binaryTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+func writeFile(w http.ResponseWriter, file *File) error {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if file.Name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	}
+	return writeStream(w, contentType, file.Reader)
+}
+
+// 不设置 Content-Length，逐块写出并刷新，由 net/http 采用分块传输
+func writeStream(w http.ResponseWriter, contentType string, reader io.Reader) (err error) {
+	if closer, ok := reader.(io.Closer); ok {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if reader == nil {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, e := reader.Read(buf)
+		if n > 0 {
+			if _, err = w.Write(buf[:n]); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if e == io.EOF {
+			return
+		}
+		if e != nil {
+			return e
+		}
+	}
+}
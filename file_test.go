@@ -0,0 +1,174 @@
+package iam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (p *testCloser) Close() error {
+	p.closed = true
+	return nil
+}
+
+type testErrReader struct{}
+
+func (testErrReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+type testFileService struct {
+	reader *testCloser
+}
+
+func (p *testFileService) Download(ctx context.Context) (File, error) {
+	return File{Name: "报表.csv", ContentType: "text/csv", Reader: p.reader}, nil
+}
+
+func (p *testFileService) Export(ctx context.Context) (*File, error) {
+	return nil, nil
+}
+
+func (p *testFileService) Read(ctx context.Context) (Stream, error) {
+	return strings.NewReader("raw bytes"), nil
+}
+
+func (p *testFileService) Page(ctx context.Context) (Html, error) {
+	return "<p>shop</p>", nil
+}
+
+func (p *testFileService) Note(ctx context.Context) (Text, error) {
+	return "shop", nil
+}
+
+func TestFileOutputs(t *testing.T) {
+	service := &testFileService{reader: &testCloser{Reader: strings.NewReader("id,name\n1,shop\n")}}
+	api := newTestAPI("file",
+		&Action{Type: Read, Handler: service.Download},
+		&Action{Type: Read, Handler: service.Export},
+		&Action{Type: Read, Handler: service.Read},
+		&Action{Type: Read, Handler: service.Page},
+		&Action{Type: Read, Handler: service.Note},
+	)
+	api.SetEnvelope(&Envelope{})
+	for _, c := range []struct {
+		target      string
+		contentType string
+		disposition string
+		body        string
+	}{
+		{"/Download", "text/csv", "attachment; filename*=utf-8''%E6%8A%A5%E8%A1%A8.csv", "id,name\n1,shop\n"},
+		{"/Export", "application/octet-stream", "", ""},
+		{"/Read", "application/octet-stream", "", "raw bytes"},
+		{"/Page", "text/html; charset=utf-8", "", "<p>shop</p>"},
+		{"/Note", "text/plain; charset=utf-8", "", "shop"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, c.target, nil)
+		r.Header.Set("Accept", "application/x-protobuf")
+		w := serve(t, api, r)
+		assert.Equal(t, http.StatusOK, w.Code, c.target)
+		assert.Equal(t, c.contentType, w.Header().Get("Content-Type"), c.target)
+		assert.Equal(t, c.disposition, w.Header().Get("Content-Disposition"), c.target)
+		assert.Empty(t, w.Header().Get("Content-Length"), c.target)
+		assert.Equal(t, c.body, w.Body.String(), c.target)
+	}
+	assert.True(t, service.reader.closed)
+}
+
+func TestWriteStreamError(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.EqualError(t, writeStream(w, "application/octet-stream", testErrReader{}), "read failed")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestBinaryMethods(t *testing.T) {
+	service := new(testFileService)
+	api := newTestAPI("file",
+		&Action{Type: Read, Handler: service.Download},
+		&Action{Type: Read, Handler: service.Read},
+		&Action{Type: Read, Handler: service.Page},
+	)
+	api.SetExporter("", nil)
+	_, err := api.Handler()
+	assert.NoError(t, err)
+	binary := map[string]bool{}
+	for _, v := range api.methods {
+		binary[v.Name] = v.Binary
+	}
+	assert.Equal(t, map[string]bool{"Download": true, "Read": true, "Page": false}, binary)
+}
+
+type testAttachmentIn struct {
+	ShopId int64                   `form:"shopId"`
+	Avatar *multipart.FileHeader   `form:"avatar"`
+	Photos []*multipart.FileHeader `form:"photos"`
+}
+
+type testAttachmentOut struct {
+	ShopId int64    `json:"shopId"`
+	Avatar string   `json:"avatar"`
+	Photos []string `json:"photos"`
+}
+
+func (p *testFileService) Attach(ctx context.Context, in *testAttachmentIn) (*testAttachmentOut, error) {
+	out := &testAttachmentOut{ShopId: in.ShopId}
+	if in.Avatar != nil {
+		f, err := in.Avatar.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		out.Avatar = in.Avatar.Filename + ":" + string(data)
+	}
+	for _, v := range in.Photos {
+		out.Photos = append(out.Photos, v.Filename)
+	}
+	return out, nil
+}
+
+func TestMultipartUpload(t *testing.T) {
+	service := new(testFileService)
+	api := newTestAPI("file", &Action{Type: Write, Handler: service.Attach})
+	api.SetExporter("", nil)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("shopId", "42"))
+	for _, v := range []struct {
+		field string
+		name  string
+	}{{"avatar", "a.png"}, {"photos", "p1.png"}, {"photos", "p2.png"}} {
+		part, err := writer.CreateFormFile(v.field, v.name)
+		require.NoError(t, err)
+		_, err = part.Write([]byte("data"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	r := httptest.NewRequest(http.MethodPost, "/Attach", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	w := serve(t, api, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"shopId":42,"avatar":"a.png:data","photos":["p1.png","p2.png"]}`, w.Body.String())
+
+	require.Len(t, api.methods, 1)
+	assert.True(t, api.methods[0].Multipart)
+}
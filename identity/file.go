@@ -0,0 +1,188 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/utilslab/iam/policy"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var _ Store = new(FileStore)
+
+// Snapshot 身份数据文件格式
+type Snapshot struct {
+	Principals []*Principal `json:"principals,omitempty" yaml:"principals,omitempty"`
+	Roles      []*Role      `json:"roles,omitempty" yaml:"roles,omitempty"`
+	UserGroups []*UserGroup `json:"userGroups,omitempty" yaml:"userGroups,omitempty"`
+}
+
+// NewFileStore 从 JSON 或 YAML 文件加载身份存储，按扩展名 .json、.yaml、.yml 选择格式，文件不存在时创建空存储
+func NewFileStore(path string) (store *FileStore, err error) {
+	store = &FileStore{path: path, memory: NewMemoryStore()}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		store.yaml = false
+	case ".yaml", ".yml":
+		store.yaml = true
+	default:
+		err = fmt.Errorf("identity file '%s' unsupported, expect .json, .yaml or .yml", path)
+		return
+	}
+	err = store.load()
+	return
+}
+
+// FileStore 文件身份存储，数据常驻内存，写操作后整体回写文件
+type FileStore struct {
+	mu       sync.Mutex   // 串行化写操作
+	memoryMu sync.RWMutex // 保护 memory 的替换
+	path     string
+	yaml     bool
+	memory   *MemoryStore
+}
+
+func (p *FileStore) load() (err error) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	snapshot := new(Snapshot)
+	if p.yaml {
+		err = yaml.Unmarshal(data, snapshot)
+	} else {
+		err = json.Unmarshal(data, snapshot)
+	}
+	if err != nil {
+		err = fmt.Errorf("decode identity file '%s' error: %s", p.path, err)
+		return
+	}
+	ctx := context.Background()
+	for _, v := range snapshot.Principals {
+		if err = validatePolicies(v.Policies); err != nil {
+			return fmt.Errorf("principal '%s' %s", v.Id, err)
+		}
+		if err = p.memory.PutPrincipal(ctx, v); err != nil {
+			return
+		}
+	}
+	for _, v := range snapshot.Roles {
+		if err = validatePolicies(v.Policies); err != nil {
+			return fmt.Errorf("role '%s' %s", v.Id, err)
+		}
+		if err = p.memory.PutRole(ctx, v); err != nil {
+			return
+		}
+	}
+	for _, v := range snapshot.UserGroups {
+		if err = validatePolicies(v.Policies); err != nil {
+			return fmt.Errorf("user group '%s' %s", v.Id, err)
+		}
+		if err = p.memory.PutUserGroup(ctx, v); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p *FileStore) save(memory *MemoryStore) (err error) {
+	snapshot := memory.snapshot()
+	var data []byte
+	if p.yaml {
+		data, err = yaml.Marshal(snapshot)
+	} else {
+		data, err = json.MarshalIndent(snapshot, "", "  ")
+	}
+	if err != nil {
+		return
+	}
+	return ioutil.WriteFile(p.path, data, 0644)
+}
+
+// 在数据副本上执行写操作，回写文件成功后才替换内存数据，失败时内存与文件保持一致
+func (p *FileStore) write(fn func(memory *MemoryStore) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	memory := p.memory.clone()
+	if err := fn(memory); err != nil {
+		return err
+	}
+	if err := p.save(memory); err != nil {
+		return err
+	}
+	p.memoryMu.Lock()
+	p.memory = memory
+	p.memoryMu.Unlock()
+	return nil
+}
+
+func (p *FileStore) current() *MemoryStore {
+	p.memoryMu.RLock()
+	defer p.memoryMu.RUnlock()
+	return p.memory
+}
+
+func (p *FileStore) GetPrincipal(ctx context.Context, id string) (*Principal, error) {
+	return p.current().GetPrincipal(ctx, id)
+}
+
+func (p *FileStore) PutPrincipal(ctx context.Context, principal *Principal) error {
+	if err := validatePolicies(principal.Policies); err != nil {
+		return err
+	}
+	return p.write(func(memory *MemoryStore) error { return memory.PutPrincipal(ctx, principal) })
+}
+
+func (p *FileStore) DeletePrincipal(ctx context.Context, id string) error {
+	return p.write(func(memory *MemoryStore) error { return memory.DeletePrincipal(ctx, id) })
+}
+
+func (p *FileStore) GetRole(ctx context.Context, id string) (*Role, error) {
+	return p.current().GetRole(ctx, id)
+}
+
+func (p *FileStore) PutRole(ctx context.Context, role *Role) error {
+	if err := validatePolicies(role.Policies); err != nil {
+		return err
+	}
+	return p.write(func(memory *MemoryStore) error { return memory.PutRole(ctx, role) })
+}
+
+func (p *FileStore) DeleteRole(ctx context.Context, id string) error {
+	return p.write(func(memory *MemoryStore) error { return memory.DeleteRole(ctx, id) })
+}
+
+func (p *FileStore) GetUserGroup(ctx context.Context, id string) (*UserGroup, error) {
+	return p.current().GetUserGroup(ctx, id)
+}
+
+func (p *FileStore) PutUserGroup(ctx context.Context, group *UserGroup) error {
+	if err := validatePolicies(group.Policies); err != nil {
+		return err
+	}
+	return p.write(func(memory *MemoryStore) error { return memory.PutUserGroup(ctx, group) })
+}
+
+func (p *FileStore) DeleteUserGroup(ctx context.Context, id string) error {
+	return p.write(func(memory *MemoryStore) error { return memory.DeleteUserGroup(ctx, id) })
+}
+
+func validatePolicies(docs []*policy.Document) error {
+	for i, v := range docs {
+		if v == nil {
+			return fmt.Errorf("policies[%d] empty", i)
+		}
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("policies[%d] %s", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package identity
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/policy"
+)
+
+func TestFileStore(t *testing.T) {
+	for _, name := range []string{"identity.json", "identity.yaml", "identity.yml"} {
+		t.Run(name, func(t *testing.T) {
+			store, err := NewFileStore(filepath.Join(t.TempDir(), name))
+			require.NoError(t, err)
+			testStore(t, store)
+		})
+	}
+	_, err := NewFileStore(filepath.Join(t.TempDir(), "identity.toml"))
+	assert.Error(t, err)
+}
+
+func TestFileStorePersist(t *testing.T) {
+	ctx := context.Background()
+	for _, name := range []string{"identity.json", "identity.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			store, err := NewFileStore(path)
+			require.NoError(t, err)
+			doc := testPolicy("cheap", "shop:*")
+			doc.Statements[0].Conditions = policy.Conditions{policy.NumericLessThan: {"$price": {"1234567"}}}
+			require.NoError(t, store.PutRole(ctx, &Role{Id: "r1", Policies: []*policy.Document{doc}}))
+			require.NoError(t, store.PutPrincipal(ctx, &Principal{Id: "u1", Roles: []string{"r1"}}))
+
+			reloaded, err := NewFileStore(path)
+			require.NoError(t, err)
+			principal, err := reloaded.GetPrincipal(ctx, "u1")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"r1"}, principal.Roles)
+			role, err := reloaded.GetRole(ctx, "r1")
+			require.NoError(t, err)
+			assert.Equal(t, []*policy.Document{doc}, role.Policies)
+		})
+	}
+}
+
+func TestFileStoreSaveFailed(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	store, err := NewFileStore(filepath.Join(dir, "identity.json"))
+	require.NoError(t, err)
+	require.NoError(t, store.PutRole(ctx, &Role{Id: "r1"}))
+
+	// 回写失败时内存数据不变
+	require.NoError(t, os.RemoveAll(dir))
+	assert.Error(t, store.PutPrincipal(ctx, &Principal{Id: "u1"}))
+	_, err = store.GetPrincipal(ctx, "u1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Error(t, store.DeleteRole(ctx, "r1"))
+	_, err = store.GetRole(ctx, "r1")
+	assert.NoError(t, err)
+}
+
+func TestFileStoreInvalid(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"identity.json", `{"principals":[{"id":"u1","policies":[{"version":"2","statements":[]}]}]}`, "principal 'u1' policies[0] policy version '2' unsupported, expect '1'"},
+		{"identity.yaml", "roles:\n- id: r1\n  policies:\n  - version: \"1\"\n    statements: []\n", "role 'r1' policies[0] policy statements empty"},
+		{"identity.json", `{"principals":`, ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.name)
+			require.NoError(t, ioutil.WriteFile(path, []byte(c.data), 0644))
+			_, err := NewFileStore(path)
+			if c.err == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.EqualError(t, err, c.err)
+		})
+	}
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "identity.json"))
+	require.NoError(t, err)
+	assert.Error(t, store.PutRole(context.Background(), &Role{Id: "r1", Policies: []*policy.Document{{Version: "1"}}}))
+}
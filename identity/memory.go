@@ -0,0 +1,144 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var _ Store = new(MemoryStore)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		principals: map[string]*Principal{},
+		roles:      map[string]*Role{},
+		groups:     map[string]*UserGroup{},
+	}
+}
+
+// MemoryStore 内存身份存储
+type MemoryStore struct {
+	mu         sync.RWMutex
+	principals map[string]*Principal
+	roles      map[string]*Role
+	groups     map[string]*UserGroup
+}
+
+func (p *MemoryStore) GetPrincipal(ctx context.Context, id string) (*Principal, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.principals[id]
+	if !ok {
+		return nil, fmt.Errorf("principal '%s' %w", id, ErrNotFound)
+	}
+	return v, nil
+}
+
+func (p *MemoryStore) PutPrincipal(ctx context.Context, principal *Principal) error {
+	if principal.Id == "" {
+		return fmt.Errorf("principal id empty")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.principals[principal.Id] = principal
+	return nil
+}
+
+func (p *MemoryStore) DeletePrincipal(ctx context.Context, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.principals, id)
+	return nil
+}
+
+func (p *MemoryStore) GetRole(ctx context.Context, id string) (*Role, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.roles[id]
+	if !ok {
+		return nil, fmt.Errorf("role '%s' %w", id, ErrNotFound)
+	}
+	return v, nil
+}
+
+func (p *MemoryStore) PutRole(ctx context.Context, role *Role) error {
+	if role.Id == "" {
+		return fmt.Errorf("role id empty")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles[role.Id] = role
+	return nil
+}
+
+func (p *MemoryStore) DeleteRole(ctx context.Context, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.roles, id)
+	return nil
+}
+
+func (p *MemoryStore) GetUserGroup(ctx context.Context, id string) (*UserGroup, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.groups[id]
+	if !ok {
+		return nil, fmt.Errorf("user group '%s' %w", id, ErrNotFound)
+	}
+	return v, nil
+}
+
+func (p *MemoryStore) PutUserGroup(ctx context.Context, group *UserGroup) error {
+	if group.Id == "" {
+		return fmt.Errorf("user group id empty")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.groups[group.Id] = group
+	return nil
+}
+
+func (p *MemoryStore) DeleteUserGroup(ctx context.Context, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.groups, id)
+	return nil
+}
+
+// 导出全部数据，按 Id 排序
+// 复制存储，身份对象本身共享
+func (p *MemoryStore) clone() *MemoryStore {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	c := NewMemoryStore()
+	for k, v := range p.principals {
+		c.principals[k] = v
+	}
+	for k, v := range p.roles {
+		c.roles[k] = v
+	}
+	for k, v := range p.groups {
+		c.groups[k] = v
+	}
+	return c
+}
+
+func (p *MemoryStore) snapshot() *Snapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s := new(Snapshot)
+	for _, v := range p.principals {
+		s.Principals = append(s.Principals, v)
+	}
+	for _, v := range p.roles {
+		s.Roles = append(s.Roles, v)
+	}
+	for _, v := range p.groups {
+		s.UserGroups = append(s.UserGroups, v)
+	}
+	sort.Slice(s.Principals, func(i, j int) bool { return s.Principals[i].Id < s.Principals[j].Id })
+	sort.Slice(s.Roles, func(i, j int) bool { return s.Roles[i].Id < s.Roles[j].Id })
+	sort.Slice(s.UserGroups, func(i, j int) bool { return s.UserGroups[i].Id < s.UserGroups[j].Id })
+	return s
+}
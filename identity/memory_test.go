@@ -0,0 +1,121 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/utilslab/iam/policy"
+)
+
+func testPolicy(name string, actions ...string) *policy.Document {
+	return &policy.Document{Version: policy.Version, Name: name, Statements: []*policy.Statement{
+		{Effect: policy.Allow, Actions: actions, Resources: []string{"*"}},
+	}}
+}
+
+// 对各存储实现执行相同的读写检查
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		name   string
+		put    func() error
+		get    func() (interface{}, error)
+		delete func() error
+		want   interface{}
+	}{
+		{
+			name: "principal",
+			put: func() error {
+				return store.PutPrincipal(ctx, &Principal{Id: "u1", Name: "Alice", Roles: []string{"r1"}})
+			},
+			get:    func() (interface{}, error) { return store.GetPrincipal(ctx, "u1") },
+			delete: func() error { return store.DeletePrincipal(ctx, "u1") },
+			want:   &Principal{Id: "u1", Name: "Alice", Roles: []string{"r1"}},
+		},
+		{
+			name: "role",
+			put: func() error {
+				return store.PutRole(ctx, &Role{Id: "r1", Policies: []*policy.Document{testPolicy("shop", "shop:*")}})
+			},
+			get:    func() (interface{}, error) { return store.GetRole(ctx, "r1") },
+			delete: func() error { return store.DeleteRole(ctx, "r1") },
+			want:   &Role{Id: "r1", Policies: []*policy.Document{testPolicy("shop", "shop:*")}},
+		},
+		{
+			name:   "user group",
+			put:    func() error { return store.PutUserGroup(ctx, &UserGroup{Id: "g1", Roles: []string{"r1"}}) },
+			get:    func() (interface{}, error) { return store.GetUserGroup(ctx, "g1") },
+			delete: func() error { return store.DeleteUserGroup(ctx, "g1") },
+			want:   &UserGroup{Id: "g1", Roles: []string{"r1"}},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.get()
+			assert.True(t, errors.Is(err, ErrNotFound), "%v", err)
+			assert.NoError(t, c.put())
+			v, err := c.get()
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, v)
+			assert.NoError(t, c.delete())
+			_, err = c.get()
+			assert.True(t, errors.Is(err, ErrNotFound), "%v", err)
+		})
+	}
+	assert.EqualError(t, store.PutPrincipal(ctx, &Principal{}), "principal id empty")
+	assert.EqualError(t, store.PutRole(ctx, &Role{}), "role id empty")
+	assert.EqualError(t, store.PutUserGroup(ctx, &UserGroup{}), "user group id empty")
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	assert.NoError(t, store.PutRole(ctx, &Role{Id: "reader", Policies: []*policy.Document{testPolicy("reader", "*:Get*")}}))
+	assert.NoError(t, store.PutRole(ctx, &Role{Id: "writer", Policies: []*policy.Document{testPolicy("writer", "*:Add*")}}))
+	assert.NoError(t, store.PutUserGroup(ctx, &UserGroup{Id: "staff", Roles: []string{"reader", "writer"}, Policies: []*policy.Document{testPolicy("staff", "shop:*")}}))
+	for _, c := range []struct {
+		name      string
+		principal *Principal
+		want      []string
+		err       bool
+	}{
+		{"own", &Principal{Id: "u1", Policies: []*policy.Document{testPolicy("own", "*")}}, []string{"own"}, false},
+		{"roles", &Principal{Id: "u1", Roles: []string{"reader"}}, []string{"reader"}, false},
+		{"group roles", &Principal{Id: "u1", Groups: []string{"staff"}}, []string{"staff", "reader", "writer"}, false},
+		{"role once", &Principal{Id: "u1", Roles: []string{"reader"}, Groups: []string{"staff"}}, []string{"reader", "staff", "writer"}, false},
+		{"role missing", &Principal{Id: "u1", Roles: []string{"admin"}}, nil, true},
+		{"group missing", &Principal{Id: "u1", Groups: []string{"admin"}}, nil, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			docs, err := Policies(ctx, store, c.principal)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var names []string
+			for _, v := range docs {
+				names = append(names, v.Name)
+			}
+			assert.Equal(t, c.want, names)
+		})
+	}
+}
+
+func TestPolicySource(t *testing.T) {
+	ctx := context.Background()
+	source := PolicySource(NewMemoryStore())
+	docs, err := source(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, docs)
+
+	principal := &Principal{Id: "u1", Policies: []*policy.Document{testPolicy("own", "*")}}
+	docs, err = source(WithPrincipal(ctx, principal))
+	assert.NoError(t, err)
+	assert.Equal(t, principal.Policies, docs)
+}
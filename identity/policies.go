@@ -0,0 +1,56 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"github.com/utilslab/iam/policy"
+)
+
+// Policies 汇总调用者自身、所属角色、所属用户组及用户组角色的策略
+func Policies(ctx context.Context, store Store, principal *Principal) (docs []*policy.Document, err error) {
+	docs = append(docs, principal.Policies...)
+	roles := map[string]bool{}
+	addRoles := func(ids []string) error {
+		for _, id := range ids {
+			if roles[id] {
+				continue
+			}
+			roles[id] = true
+			role, err := store.GetRole(ctx, id)
+			if err != nil {
+				return fmt.Errorf("get role '%s' error: %s", id, err)
+			}
+			docs = append(docs, role.Policies...)
+		}
+		return nil
+	}
+	if err = addRoles(principal.Roles); err != nil {
+		return
+	}
+	for _, id := range principal.Groups {
+		var group *UserGroup
+		group, err = store.GetUserGroup(ctx, id)
+		if err != nil {
+			err = fmt.Errorf("get user group '%s' error: %s", id, err)
+			return
+		}
+		docs = append(docs, group.Policies...)
+		if err = addRoles(group.Roles); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// PolicySource 返回从上下文调用者解析策略的函数，可用于 iam.NewPolicyAuthorizer
+//
+// 上下文中没有调用者时返回空策略，即隐式拒绝
+func PolicySource(store Store) func(ctx context.Context) ([]*policy.Document, error) {
+	return func(ctx context.Context) ([]*policy.Document, error) {
+		principal, ok := FromContext(ctx)
+		if !ok {
+			return nil, nil
+		}
+		return Policies(ctx, store, principal)
+	}
+}
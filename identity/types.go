@@ -0,0 +1,73 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"github.com/utilslab/iam/policy"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Principal 调用者，可直接关联策略，也可通过角色、用户组继承策略
+type Principal struct {
+	Id       string             `json:"id" yaml:"id"`
+	Name     string             `json:"name,omitempty" yaml:"name,omitempty"`
+	Roles    []string           `json:"roles,omitempty" yaml:"roles,omitempty"`   // 角色 Id
+	Groups   []string           `json:"groups,omitempty" yaml:"groups,omitempty"` // 用户组 Id
+	Policies []*policy.Document `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// Role 角色
+type Role struct {
+	Id       string             `json:"id" yaml:"id"`
+	Name     string             `json:"name,omitempty" yaml:"name,omitempty"`
+	Policies []*policy.Document `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// UserGroup 用户组，组内成员继承用户组及其角色的策略
+type UserGroup struct {
+	Id       string             `json:"id" yaml:"id"`
+	Name     string             `json:"name,omitempty" yaml:"name,omitempty"`
+	Roles    []string           `json:"roles,omitempty" yaml:"roles,omitempty"` // 角色 Id
+	Policies []*policy.Document `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// Store 身份存储
+type Store interface {
+	GetPrincipal(ctx context.Context, id string) (*Principal, error)
+	PutPrincipal(ctx context.Context, principal *Principal) error
+	DeletePrincipal(ctx context.Context, id string) error
+	GetRole(ctx context.Context, id string) (*Role, error)
+	PutRole(ctx context.Context, role *Role) error
+	DeleteRole(ctx context.Context, id string) error
+	GetUserGroup(ctx context.Context, id string) (*UserGroup, error)
+	PutUserGroup(ctx context.Context, group *UserGroup) error
+	DeleteUserGroup(ctx context.Context, id string) error
+}
+
+type principalKey struct{}
+
+// WithPrincipal 将调用者放入上下文，通常在 ContextWrapper 中调用
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext 从上下文中获取调用者
+func FromContext(ctx context.Context) (principal *Principal, ok bool) {
+	principal, ok = ctx.Value(principalKey{}).(*Principal)
+	if principal == nil {
+		ok = false
+	}
+	return
+}
+
+// Loader 返回按 Id 加载调用者并放入上下文的函数，可用于 API.SetPrincipalLoader
+func Loader(store Store) func(ctx context.Context, id string) (context.Context, error) {
+	return func(ctx context.Context, id string) (context.Context, error) {
+		principal, err := store.GetPrincipal(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return WithPrincipal(ctx, principal), nil
+	}
+}
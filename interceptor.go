@@ -0,0 +1,91 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ActionInfo 拦截器可见的 Action 信息
+type ActionInfo struct {
+	Group      string
+	Name       string
+	Permission string
+	Type       ActionType
+	Method     string
+	Path       string
+	Action     *Action
+}
+
+// Invoker 调用链的下一环，最内层为服务方法本身
+//
+// in 为绑定后的入参，无入参的服务方法为 nil；返回值为服务方法的输出，仅返回 error 的服务方法为 nil
+type Invoker func(ctx context.Context, in interface{}) (interface{}, error)
+
+// Interceptor 服务方法拦截器，可读取或替换入参、输出及错误，不调用 next 即可短路
+type Interceptor func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error)
+
+// Use 添加 API 级拦截器，作用于全部 Action
+//
+// 拦截器按 API、Route、Group、Action 的顺序由外向内执行，同级按添加顺序执行
+func (p *API) Use(interceptors ...Interceptor) {
+	p.interceptors = append(p.interceptors, interceptors...)
+}
+
+func (p *API) actionInfo(endpoint *Endpoint) ActionInfo {
+	action := endpoint.Action
+	return ActionInfo{
+		Group:      action.group,
+		Name:       action.name,
+		Permission: permissionName(action.group, action.name),
+		Type:       action.Type,
+		Method:     endpoint.Method,
+		Path:       endpoint.Path,
+		Action:     action,
+	}
+}
+
+// 按 API、Route、Group、Action 的顺序组装拦截链
+func (p *API) buildInvoker(endpoint *Endpoint) Invoker {
+	var interceptors []Interceptor
+	interceptors = append(interceptors, p.interceptors...)
+	interceptors = append(interceptors, endpoint.Route.Interceptors...)
+	interceptors = append(interceptors, endpoint.Group.Interceptors...)
+	interceptors = append(interceptors, endpoint.Action.Interceptors...)
+	invoker := invokeHandler(endpoint.Action.handler)
+	if len(interceptors) == 0 {
+		return invoker
+	}
+	info := p.actionInfo(endpoint)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, in interface{}) (interface{}, error) {
+			return interceptor(ctx, info, in, next)
+		}
+	}
+	return invoker
+}
+
+// 反射调用服务方法
+func invokeHandler(handler reflect.Value) Invoker {
+	t := handler.Type()
+	return func(ctx context.Context, in interface{}) (interface{}, error) {
+		args := []reflect.Value{reflect.ValueOf(ctx)}
+		if t.NumIn() == 2 {
+			v := reflect.ValueOf(in)
+			if !v.IsValid() || v.Type() != t.In(1) {
+				return nil, fmt.Errorf("unexpect input type %T, expect %s", in, t.In(1))
+			}
+			args = append(args, v)
+		}
+		out := handler.Call(args)
+		l := len(out)
+		if !out[l-1].IsNil() {
+			return nil, out[l-1].Interface().(error)
+		}
+		if l == 2 {
+			return out[0].Interface(), nil
+		}
+		return nil, nil
+	}
+}
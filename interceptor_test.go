@@ -0,0 +1,101 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 记录调用顺序的拦截器
+func recordInterceptor(name string, calls *[]string) Interceptor {
+	return func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+		*calls = append(*calls, name)
+		out, err := next(ctx, in)
+		*calls = append(*calls, name+" done")
+		return out, err
+	}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	var info ActionInfo
+	service := new(testShopService)
+	api := New()
+	api.Use(recordInterceptor("api", &calls))
+	api.AddRouter(testRouter{{
+		Prefix:       "/api",
+		Interceptors: []Interceptor{recordInterceptor("route", &calls)},
+		Groups: []*Group{{
+			Name:         "shop",
+			Interceptors: []Interceptor{recordInterceptor("group", &calls)},
+			Actions: []*Action{{
+				Type: Read,
+				Interceptors: []Interceptor{
+					recordInterceptor("action", &calls),
+					func(ctx context.Context, v ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+						info = v
+						return next(ctx, in)
+					},
+				},
+				Handler: service.GetShop,
+			}},
+		}},
+	}})
+	w := serve(t, api, httptest.NewRequest(http.MethodGet, "/api/GetShop?shopId=42", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"api", "route", "group", "action", "action done", "group done", "route done", "api done"}, calls)
+	assert.Equal(t, 1, service.calls)
+	assert.Equal(t, "shop", info.Group)
+	assert.Equal(t, "GetShop", info.Name)
+	assert.Equal(t, "shop:GetShop", info.Permission)
+	assert.Equal(t, Read, info.Type)
+	assert.Equal(t, http.MethodGet, info.Method)
+	assert.Equal(t, "/api/GetShop", info.Path)
+}
+
+func TestInterceptor(t *testing.T) {
+	for _, c := range []struct {
+		name        string
+		interceptor Interceptor
+		status      int
+		body        string
+		calls       int
+	}{
+		{"short circuit", func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+			return &testShopOut{ShopId: 1}, nil
+		}, http.StatusOK, `{"shopId":1,"color":""}`, 0},
+		{"replace input", func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+			in.(*testShopIn).Color = "red"
+			return next(ctx, in)
+		}, http.StatusOK, `{"shopId":42,"color":"red"}`, 1},
+		{"replace output", func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+			out, err := next(ctx, in)
+			out.(*testShopOut).Color = "blue"
+			return out, err
+		}, http.StatusOK, `{"shopId":42,"color":"blue"}`, 1},
+		{"error", func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+			return nil, errors.New("rejected")
+		}, http.StatusBadRequest, "rejected", 0},
+		{"input type mismatch", func(ctx context.Context, info ActionInfo, in interface{}, next Invoker) (interface{}, error) {
+			return next(ctx, testShopIn{})
+		}, http.StatusBadRequest, "unexpect input type iam.testShopIn, expect *iam.testShopIn", 0},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			service := new(testShopService)
+			api := newTestAPI("shop", &Action{Type: Read, Handler: service.GetShop})
+			api.Use(c.interceptor)
+			w := serve(t, api, httptest.NewRequest(http.MethodGet, "/GetShop?shopId=42", nil))
+			assert.Equal(t, c.status, w.Code)
+			if c.status == http.StatusOK {
+				assert.JSONEq(t, c.body, w.Body.String())
+			} else {
+				assert.Equal(t, c.body, w.Body.String())
+			}
+			assert.Equal(t, c.calls, service.calls)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// Hook 生命周期钩子
+type Hook func(ctx context.Context) error
+
+func (p *API) SetAddr(addr string) {
+	p.addr = addr
+}
+
+// SetShutdownTimeout 设置收到退出信号后等待请求处理完成的最长时间
+func (p *API) SetShutdownTimeout(timeout time.Duration) {
+	p.shutdownTimeout = timeout
+}
+
+// OnStart 添加启动钩子，在路由注册完成、开始监听前按顺序执行，任一钩子出错将终止启动
+func (p *API) OnStart(hooks ...Hook) {
+	p.onStart = append(p.onStart, hooks...)
+}
+
+// OnStop 添加停止钩子，在服务关闭、进行中的请求处理完成后按顺序执行
+func (p *API) OnStop(hooks ...Hook) {
+	p.onStop = append(p.onStop, hooks...)
+}
+
+// 注册路由并初始化导出器，仅执行一次
+func (p *API) prepare() (err error) {
+	if p.driver == nil {
+		p.driver = NewGinDriver(nil)
+	}
+	if err = p.Validate(); err != nil {
+		return
+	}
+	if p.registered {
+		return
+	}
+	p.buildEndpoints()
+	p.collect()
+	if err = p.driver.Register(p); err != nil {
+		return
+	}
+	p.registered = true
+	if p.printRoutes {
+		p.routeTable.Print()
+	}
+	if p.exporter != nil {
+		p.exporter.Init(p.version, p.methods, p.models)
+		p.exporter.SetPermissions(p.permissions)
+		p.exporter.SetRoutes(p.exporterRoutes())
+		if p.simulateEndpoint {
+			p.exporter.SetSimulator(p.simulator)
+		}
+	}
+	return
+}
+
+// 可提供 http.Handler 的驱动
+type handlerDriver interface {
+	Handler() http.Handler
+}
+
+// SetExporterPrefix 将导出器挂载到 API 处理器的 prefix 路径下，不再单独监听端口
+func (p *API) SetExporterPrefix(prefix string) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	p.exporterPrefix = prefix
+}
+
+// Handler 完成路由准备并返回 API 的 HTTP 处理器，可通过 http.StripPrefix 挂载到已有服务的子路径下，
+// 设置 SetExporterPrefix 后导出器将一同挂载在该处理器上
+func (p *API) Handler() (http.Handler, error) {
+	if err := p.prepare(); err != nil {
+		return nil, err
+	}
+	driver, ok := p.driver.(handlerDriver)
+	if !ok {
+		return nil, fmt.Errorf("driver %T does not provide http.Handler", p.driver)
+	}
+	if p.exporter == nil || p.exporterPrefix == "" {
+		return driver.Handler(), nil
+	}
+	return &mountHandler{
+		api:      driver.Handler(),
+		exporter: p.exporter.Handler(),
+		prefix:   p.exporterPrefix,
+	}, nil
+}
+
+// 将导出器挂载在 prefix 下，其余请求交由 API 处理
+type mountHandler struct {
+	api      http.Handler
+	exporter http.Handler
+	prefix   string
+}
+
+func (p *mountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == p.prefix:
+		// 补全末尾斜杠，保证导出器内的相对地址可用
+		w.Header().Set("Location", path.Base(p.prefix)+"/")
+		w.WriteHeader(http.StatusMovedPermanently)
+	case strings.HasPrefix(r.URL.Path, p.prefix+"/"):
+		http.StripPrefix(p.prefix, p.exporter).ServeHTTP(w, r)
+	default:
+		p.api.ServeHTTP(w, r)
+	}
+}
+
+// Start 启动 API 及导出器服务，阻塞至 ctx 结束、收到 SIGINT/SIGTERM 或调用 Shutdown，随后优雅关闭
+func (p *API) Start(ctx context.Context) (err error) {
+	if err = p.prepare(); err != nil {
+		return
+	}
+	stopped := p.stopSignal()
+	if p.isStopping() {
+		// 启动前已调用 Shutdown
+		return
+	}
+	var servers []*http.Server
+	errs := make(chan error, 2)
+	if _, ok := p.driver.(handlerDriver); ok {
+		var handler http.Handler
+		if handler, err = p.Handler(); err != nil {
+			return
+		}
+		servers = append(servers, &http.Server{Addr: p.addr, Handler: handler})
+	} else {
+		// 驱动未提供 Handler 时由驱动自行监听，无法优雅关闭
+		go func() {
+			errs <- p.driver.Start(p.addr)
+		}()
+	}
+	if p.exporter != nil && p.exporterPrefix == "" {
+		servers = append(servers, p.exporter.Server())
+	}
+	var listeners []net.Listener
+	for _, server := range servers {
+		var ln net.Listener
+		ln, err = net.Listen("tcp", server.Addr)
+		if err != nil {
+			for _, v := range listeners {
+				_ = v.Close()
+			}
+			return fmt.Errorf("listen '%s' error: %s", server.Addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	for _, hook := range p.onStart {
+		if err = hook(ctx); err != nil {
+			for _, v := range listeners {
+				_ = v.Close()
+			}
+			return fmt.Errorf("start hook error: %s", err)
+		}
+	}
+	p.mu.Lock()
+	if p.stopping {
+		// 启动期间已调用 Shutdown，不再开始服务
+		p.mu.Unlock()
+		for _, v := range listeners {
+			_ = v.Close()
+		}
+		return
+	}
+	p.servers = servers
+	p.mu.Unlock()
+	for i, server := range servers {
+		go func(server *http.Server, ln net.Listener) {
+			if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				errs <- fmt.Errorf("serve '%s' error: %s", server.Addr, err)
+			}
+		}(server, listeners[i])
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case <-stopped:
+		return
+	case <-ctx.Done():
+	case <-signals:
+	case err = <-errs:
+	}
+	timeout := p.shutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if e := p.Shutdown(shutdownCtx); e != nil && err == nil {
+		err = e
+	}
+	return
+}
+
+// Shutdown 停止接收新请求，等待进行中的请求处理完成后执行停止钩子
+func (p *API) Shutdown(ctx context.Context) (err error) {
+	stopped := p.stopSignal()
+	p.stopOnce.Do(func() {
+		p.mu.Lock()
+		p.stopping = true
+		servers := p.servers
+		p.mu.Unlock()
+		for _, server := range servers {
+			if e := server.Shutdown(ctx); e != nil && err == nil {
+				err = fmt.Errorf("shutdown '%s' error: %s", server.Addr, e)
+			}
+		}
+		for _, hook := range p.onStop {
+			if e := hook(ctx); e != nil && err == nil {
+				err = fmt.Errorf("stop hook error: %s", e)
+			}
+		}
+		close(stopped)
+	})
+	return
+}
+
+// 返回停止信号，Shutdown 完成后关闭
+func (p *API) stopSignal() chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped == nil {
+		p.stopped = make(chan struct{})
+	}
+	return p.stopped
+}
+
+func (p *API) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
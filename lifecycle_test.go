@@ -0,0 +1,203 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycleAPI(events *[]string) *API {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetAddr("127.0.0.1:0")
+	api.OnStart(func(ctx context.Context) error {
+		*events = append(*events, "start")
+		return nil
+	})
+	api.OnStop(func(ctx context.Context) error {
+		*events = append(*events, "stop")
+		return nil
+	})
+	return api
+}
+
+// 在后台启动，返回 Start 的结果
+func startAsync(api *API, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- api.Start(ctx)
+	}()
+	return done
+}
+
+func waitStart(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return")
+		return nil
+	}
+}
+
+func TestStartShutdown(t *testing.T) {
+	var events []string
+	api := newLifecycleAPI(&events)
+	started := make(chan struct{})
+	api.OnStart(func(ctx context.Context) error {
+		close(started)
+		return nil
+	})
+	done := startAsync(api, context.Background())
+	<-started
+	require.NoError(t, api.Shutdown(context.Background()))
+	assert.NoError(t, waitStart(t, done))
+	assert.Equal(t, []string{"start", "stop"}, events)
+	// 重复调用不再执行停止钩子
+	assert.NoError(t, api.Shutdown(context.Background()))
+	assert.Equal(t, []string{"start", "stop"}, events)
+}
+
+func TestStartContextDone(t *testing.T) {
+	var events []string
+	api := newLifecycleAPI(&events)
+	ctx, cancel := context.WithCancel(context.Background())
+	api.OnStart(func(ctx context.Context) error {
+		cancel()
+		return nil
+	})
+	assert.NoError(t, waitStart(t, startAsync(api, ctx)))
+	assert.Equal(t, []string{"start", "stop"}, events)
+}
+
+func TestShutdownBeforeStart(t *testing.T) {
+	var events []string
+	api := newLifecycleAPI(&events)
+	require.NoError(t, api.Shutdown(context.Background()))
+	assert.NoError(t, waitStart(t, startAsync(api, context.Background())))
+	assert.Equal(t, []string{"stop"}, events)
+}
+
+func TestStartHookError(t *testing.T) {
+	var events []string
+	api := newLifecycleAPI(&events)
+	api.OnStart(func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, waitStart(t, startAsync(api, context.Background())), "start hook error: boom")
+	assert.Equal(t, []string{"start"}, events)
+}
+
+func TestStartListenError(t *testing.T) {
+	var events []string
+	api := newLifecycleAPI(&events)
+	api.SetAddr("127.0.0.1:-1")
+	err := waitStart(t, startAsync(api, context.Background()))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "listen '127.0.0.1:-1' error")
+	}
+	assert.Empty(t, events)
+}
+
+type testDriver struct{}
+
+func (p testDriver) Register(api *API) error {
+	return nil
+}
+
+func (p testDriver) Start(addr string) error {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetExporter("", nil)
+	api.SetExporterPrefix("/_debug/")
+	handler, err := api.Handler()
+	require.NoError(t, err)
+
+	// 挂载到已有服务的子路径下
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", handler))
+	for _, c := range []struct {
+		target   string
+		status   int
+		location string
+	}{
+		{"/api/GetShop?shopId=42", http.StatusOK, ""},
+		{"/api/_debug", http.StatusMovedPermanently, "_debug/"},
+		{"/api/_debug/routes", http.StatusOK, ""},
+		{"/api/_debugger", http.StatusNotFound, ""},
+		{"/api/Missing", http.StatusNotFound, ""},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.target, nil))
+		assert.Equal(t, c.status, w.Code, c.target)
+		assert.Equal(t, c.location, w.Header().Get("Location"), c.target)
+	}
+
+	// 未设置前缀时不挂载导出器
+	api = newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetExporter("", nil)
+	w := serve(t, api, httptest.NewRequest(http.MethodGet, "/routes", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read})
+	_, err := api.Handler()
+	assert.Error(t, err)
+
+	api = newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetDriver(testDriver{})
+	_, err = api.Handler()
+	assert.EqualError(t, err, "driver iam.testDriver does not provide http.Handler")
+}
+
+func TestHandlerPrepareOnce(t *testing.T) {
+	var starts int
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.OnStart(func(ctx context.Context) error {
+		starts++
+		return nil
+	})
+	first, err := api.Handler()
+	require.NoError(t, err)
+	second, err := api.Handler()
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, api.RouteTable().Rows(), 1)
+	assert.Zero(t, starts)
+}
+
+func TestHandlerOpenAPI(t *testing.T) {
+	api := newTestAPI("shop", &Action{Type: Read, Handler: new(testShopService).GetShop})
+	api.SetVersion("1.0.0")
+	api.SetExporter("", nil)
+	api.SetExporterPrefix("/_debug/")
+	for _, c := range []struct {
+		target      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/_debug/openapi.json", http.StatusOK, "application/json; charset=utf-8", `"openapi":"3.0.3"`},
+		{"/_debug/openapi.json?version=3.1", http.StatusOK, "application/json; charset=utf-8", `"openapi":"3.1.0"`},
+		{"/_debug/openapi.yaml", http.StatusOK, "application/x-yaml; charset=utf-8", "openapi: 3.0.3\n"},
+		{"/_debug/openapi.json?version=2.0", http.StatusBadRequest, "text/plain; charset=utf-8", "unsupported openapi version '2.0'"},
+	} {
+		w := serve(t, api, httptest.NewRequest(http.MethodGet, c.target, nil))
+		assert.Equal(t, c.status, w.Code, c.target)
+		assert.Equal(t, c.contentType, w.Header().Get("Content-Type"), c.target)
+		assert.Contains(t, w.Body.String(), c.body, c.target)
+		if c.status == http.StatusOK {
+			assert.Contains(t, w.Body.String(), "GetShop", c.target)
+		}
+	}
+}
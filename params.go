@@ -0,0 +1,193 @@
+package iam
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// CodeInvalidParams 入参校验失败的错误码，错误详情为 []*FieldError
+const CodeInvalidParams = "InvalidParams"
+
+// FieldError 入参字段的校验错误
+type FieldError struct {
+	Field   string `json:"field" xml:"field" yaml:"field"`                               // 字段参数路径，如 items[0].sku
+	Rule    string `json:"rule" xml:"rule" yaml:"rule"`                                  // 未通过的校验规则，如 required、max
+	Param   string `json:"param,omitempty" xml:"param,omitempty" yaml:"param,omitempty"` // 校验规则参数
+	Message string `json:"message" xml:"message" yaml:"message"`                         // 按 Accept-Language 翻译的提示信息
+}
+
+var fieldErrorsType = reflect.TypeOf([]*FieldError{})
+
+// 默认提示信息，{label} 为字段的 label 标签（未设置时为参数名），{param} 为规则参数
+//
+// 规则名追加 .length 的条目作用于字符串、数组与字典，比较长度
+var defaultMessages = map[string]map[string]string{
+	"en": {
+		"default":    "{label} failed on the '{rule}' rule",
+		"required":   "{label} is required",
+		"min":        "{label} must be {param} or greater",
+		"min.length": "{label} must be at least {param} in length",
+		"max":        "{label} must be {param} or less",
+		"max.length": "{label} must be at most {param} in length",
+		"gte":        "{label} must be {param} or greater",
+		"gte.length": "{label} must be at least {param} in length",
+		"lte":        "{label} must be {param} or less",
+		"lte.length": "{label} must be at most {param} in length",
+		"gt":         "{label} must be greater than {param}",
+		"gt.length":  "{label} must be longer than {param}",
+		"lt":         "{label} must be less than {param}",
+		"lt.length":  "{label} must be shorter than {param}",
+		"len":        "{label} must be {param}",
+		"len.length": "{label} must be {param} in length",
+		"oneof":      "{label} must be one of [{param}]",
+		"email":      "{label} must be a valid email address",
+		"url":        "{label} must be a valid URL",
+		"uri":        "{label} must be a valid URI",
+		"regex":      "{label} has an invalid format",
+	},
+	"zh": {
+		"default":    "{label}未通过{rule}校验",
+		"required":   "{label}为必填字段",
+		"min":        "{label}不能小于{param}",
+		"min.length": "{label}长度不能小于{param}",
+		"max":        "{label}不能大于{param}",
+		"max.length": "{label}长度不能大于{param}",
+		"gte":        "{label}不能小于{param}",
+		"gte.length": "{label}长度不能小于{param}",
+		"lte":        "{label}不能大于{param}",
+		"lte.length": "{label}长度不能大于{param}",
+		"gt":         "{label}必须大于{param}",
+		"gt.length":  "{label}长度必须大于{param}",
+		"lt":         "{label}必须小于{param}",
+		"lt.length":  "{label}长度必须小于{param}",
+		"len":        "{label}必须等于{param}",
+		"len.length": "{label}长度必须等于{param}",
+		"oneof":      "{label}必须是[{param}]中的一个",
+		"email":      "{label}必须是有效的邮箱地址",
+		"url":        "{label}必须是有效的URL",
+		"uri":        "{label}必须是有效的URI",
+		"regex":      "{label}格式不正确",
+	},
+}
+
+// defaultLanguage 请求未匹配到任何已注册语言时使用的语言
+const defaultLanguage = "en"
+
+// SetMessages 注册或覆盖某一语言的校验提示信息，如 zh、en、zh-TW，键为规则名，参见 defaultMessages
+func (p *API) SetMessages(lang string, messages map[string]string) {
+	if p.messages == nil {
+		p.messages = map[string]map[string]string{}
+	}
+	lang = strings.ToLower(lang)
+	if p.messages[lang] == nil {
+		p.messages[lang] = map[string]string{}
+	}
+	for k, v := range messages {
+		p.messages[lang][k] = v
+	}
+}
+
+// 将 binding 返回的校验错误转换为 CodedError，其余错误原样返回
+func (p *API) invalidParams(r *http.Request, t reflect.Type, err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	lang := p.language(r)
+	var fields []*FieldError
+	var messages []string
+	for _, v := range errs {
+		field, label := fieldPath(t, v.StructNamespace())
+		if label == "" {
+			label = field
+		}
+		e := &FieldError{
+			Field: field,
+			Rule:  v.Tag(),
+			Param: v.Param(),
+		}
+		e.Message = strings.NewReplacer("{label}", label, "{rule}", e.Rule, "{param}", e.Param).
+			Replace(p.message(lang, e.Rule, v.Kind()))
+		fields = append(fields, e)
+		messages = append(messages, e.Message)
+	}
+	return &CodedError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeInvalidParams,
+		Message: strings.Join(messages, "; "),
+		Details: fields,
+	}
+}
+
+// 按 Accept-Language 选择已注册的语言，先匹配完整标签，再匹配主语言，如 zh-CN 依次匹配 zh-cn、zh
+func (p *API) language(r *http.Request) string {
+	for _, v := range parseAccept(r.Header.Get("Accept-Language")) {
+		v = strings.ToLower(v)
+		for _, lang := range []string{v, strings.Split(v, "-")[0]} {
+			if p.messages[lang] != nil || defaultMessages[lang] != nil {
+				return lang
+			}
+		}
+	}
+	return defaultLanguage
+}
+
+// 查找提示信息，当前语言缺失时回退到默认语言
+func (p *API) message(lang, rule string, kind reflect.Kind) string {
+	var keys []string
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		keys = append(keys, rule+".length")
+	}
+	keys = append(keys, rule, "default")
+	for _, l := range []string{lang, defaultLanguage} {
+		for _, key := range keys {
+			for _, messages := range []map[string]string{p.messages[l], defaultMessages[l]} {
+				if v, ok := messages[key]; ok {
+					return v
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// 将 go-playground 的结构体路径（如 CreateIn.Items[0].Sku）转换为参数路径（如 items[0].sku），并返回字段的 label 标签
+func fieldPath(t reflect.Type, namespace string) (path, label string) {
+	segments := strings.Split(namespace, ".")
+	var b strings.Builder
+	for i, segment := range segments[1:] {
+		name, index := segment, ""
+		if j := strings.IndexByte(segment, '['); j >= 0 {
+			name, index = segment[:j], segment[j:]
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		label = ""
+		t = realType(t)
+		if t.Kind() != reflect.Struct {
+			b.WriteString(segment)
+			continue
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			b.WriteString(segment)
+			continue
+		}
+		param := strings.Split(f.Tag.Get("json"), ",")[0]
+		if param == "" || param == "-" {
+			param = f.Name
+		}
+		b.WriteString(param + index)
+		label = f.Tag.Get("label")
+		t = f.Type
+		for n := strings.Count(index, "["); n > 0; n-- {
+			t = realType(t).Elem()
+		}
+	}
+	return b.String(), label
+}
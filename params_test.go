@@ -0,0 +1,192 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+type testItemIn struct {
+	Sku   string `json:"sku" label:"商品编码" binding:"required"`
+	Count int    `json:"count" binding:"min=1"`
+}
+
+type testOrderIn struct {
+	Name  string        `json:"name" label:"名称" binding:"required,max=4"`
+	Color string        `json:"color" binding:"omitempty,oneof=red blue"`
+	Items []*testItemIn `json:"items" binding:"dive"`
+	Note  string        `binding:"omitempty,email"`
+}
+
+type testParamsService struct {
+	calls int
+}
+
+func (p *testParamsService) CreateOrder(ctx context.Context, in *testOrderIn) error {
+	p.calls++
+	return nil
+}
+
+type testFieldErrors struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []*FieldError `json:"details"`
+}
+
+func postInvalidParams(t *testing.T, api *API, body, language string) (int, *testFieldErrors) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/CreateOrder", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept-Language", language)
+	w := serve(t, api, r)
+	out := new(testFieldErrors)
+	if w.Code != http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w.Code, out
+}
+
+func TestInvalidParams(t *testing.T) {
+	service := new(testParamsService)
+	api := newTestAPI("order", &Action{Type: Write, Handler: service.CreateOrder})
+	body := `{"name":"abcdef","color":"green","items":[{"sku":"a","count":1},{"count":0}],"Note":"x"}`
+	for _, c := range []struct {
+		language string
+		messages []string
+	}{
+		{"", []string{
+			"名称 must be at most 4 in length",
+			"color must be one of [red blue]",
+			"商品编码 is required",
+			"items[1].count must be 1 or greater",
+			"Note must be a valid email address",
+		}},
+		{"zh-CN,zh;q=0.9", []string{
+			"名称长度不能大于4",
+			"color必须是[red blue]中的一个",
+			"商品编码为必填字段",
+			"items[1].count不能小于1",
+			"Note必须是有效的邮箱地址",
+		}},
+		{"fr-FR, en;q=0.5", []string{
+			"名称 must be at most 4 in length",
+			"color must be one of [red blue]",
+			"商品编码 is required",
+			"items[1].count must be 1 or greater",
+			"Note must be a valid email address",
+		}},
+	} {
+		t.Run(c.language, func(t *testing.T) {
+			status, out := postInvalidParams(t, api, body, c.language)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, CodeInvalidParams, out.Code)
+			assert.Equal(t, strings.Join(c.messages, "; "), out.Message)
+			require.Len(t, out.Details, 5)
+			for i, v := range []FieldError{
+				{Field: "name", Rule: "max", Param: "4"},
+				{Field: "color", Rule: "oneof", Param: "red blue"},
+				{Field: "items[1].sku", Rule: "required"},
+				{Field: "items[1].count", Rule: "min", Param: "1"},
+				{Field: "Note", Rule: "email"},
+			} {
+				v.Message = c.messages[i]
+				assert.Equal(t, &v, out.Details[i])
+			}
+		})
+	}
+	assert.Zero(t, service.calls)
+
+	status, _ := postInvalidParams(t, api, `{"name":"ab","items":[{"sku":"a","count":1}]}`, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, service.calls)
+}
+
+func TestSetMessages(t *testing.T) {
+	api := newTestAPI("order", &Action{Type: Write, Handler: new(testParamsService).CreateOrder})
+	api.SetMessages("zh", map[string]string{"required": "请填写{label}"})
+	api.SetMessages("zh-TW", map[string]string{"required": "請填寫{label}", "default": "{label}未通過{rule}"})
+	for _, c := range []struct {
+		language string
+		messages []string
+	}{
+		{"zh", []string{"请填写名称", "Note必须是有效的邮箱地址"}},
+		{"zh-TW", []string{"請填寫名称", "Note未通過email"}},
+		{"ZH-tw", []string{"請填寫名称", "Note未通過email"}},
+		{"zh-HK", []string{"请填写名称", "Note必须是有效的邮箱地址"}},
+	} {
+		_, out := postInvalidParams(t, api, `{"Note":"x"}`, c.language)
+		assert.Equal(t, strings.Join(c.messages, "; "), out.Message, c.language)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	api := New()
+	api.SetMessages("ja", map[string]string{"required": "{label}は必須です"})
+	for header, want := range map[string]string{
+		"":                   "en",
+		"zh-CN":              "zh",
+		"ja-JP,en;q=0.8":     "ja",
+		"fr;q=0.9, zh;q=0.5": "zh",
+		"de, fr":             "en",
+		"en-US;q=0.5,zh":     "zh",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", header)
+		assert.Equal(t, want, api.language(r), header)
+	}
+	// 已注册语言缺少的规则回退到内置提示，再回退到默认语言
+	assert.Equal(t, "{label}は必須です", api.message("ja", "required", reflect.String))
+	assert.Equal(t, "{label} must be {param} or greater", api.message("ja", "min", reflect.Int))
+	assert.Equal(t, "{label}长度不能小于{param}", api.message("zh", "min", reflect.Slice))
+	assert.Equal(t, "{label}未通过{rule}校验", api.message("zh", "isbn", reflect.String))
+}
+
+func TestFieldPath(t *testing.T) {
+	st := reflect.TypeOf(new(testOrderIn))
+	for _, c := range []struct {
+		namespace string
+		path      string
+		label     string
+	}{
+		{"testOrderIn.Name", "name", "名称"},
+		{"testOrderIn.Note", "Note", ""},
+		{"testOrderIn.Items[2].Sku", "items[2].sku", "商品编码"},
+		{"testOrderIn.Items[0].Count", "items[0].count", ""},
+		{"testOrderIn.Missing", "Missing", ""},
+	} {
+		path, label := fieldPath(st, c.namespace)
+		assert.Equal(t, c.path, path, c.namespace)
+		assert.Equal(t, c.label, label, c.namespace)
+	}
+}
+
+func TestInvalidParamsCode(t *testing.T) {
+	api := newTestAPI("order", &Action{Type: Write, Handler: new(testParamsService).CreateOrder})
+	api.SetExporter("", nil)
+	_, err := api.Handler()
+	require.NoError(t, err)
+	require.Len(t, api.methods, 1)
+	var code *exporter.Code
+	for _, v := range api.methods[0].Codes {
+		if v.Code == CodeInvalidParams {
+			code = v
+		}
+	}
+	require.NotNil(t, code)
+	assert.Equal(t, http.StatusBadRequest, code.Status)
+	require.NotNil(t, code.Details)
+	require.NotNil(t, code.Details.Elem)
+	var fields []string
+	for _, v := range code.Details.Elem.Fields {
+		fields = append(fields, v.Param)
+	}
+	assert.Equal(t, []string{"field", "rule", "param", "message"}, fields)
+}
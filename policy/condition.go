@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	StringEquals             = "StringEquals"
+	StringNotEquals          = "StringNotEquals"
+	StringEqualsIgnoreCase   = "StringEqualsIgnoreCase"
+	StringLike               = "StringLike"
+	StringNotLike            = "StringNotLike"
+	NumericEquals            = "NumericEquals"
+	NumericNotEquals         = "NumericNotEquals"
+	NumericLessThan          = "NumericLessThan"
+	NumericLessThanEquals    = "NumericLessThanEquals"
+	NumericGreaterThan       = "NumericGreaterThan"
+	NumericGreaterThanEquals = "NumericGreaterThanEquals"
+	Bool                     = "Bool"
+	Null                     = "Null"
+)
+
+// 比较实际值与条件值，多个条件值之间为或关系
+type operator func(actual string, present bool, values Values) bool
+
+var operators = map[string]operator{
+	StringEquals: func(actual string, present bool, values Values) bool {
+		return present && anyValue(values, func(v string) bool { return actual == v })
+	},
+	StringNotEquals: func(actual string, present bool, values Values) bool {
+		return !present || !anyValue(values, func(v string) bool { return actual == v })
+	},
+	StringEqualsIgnoreCase: func(actual string, present bool, values Values) bool {
+		return present && anyValue(values, func(v string) bool { return strings.EqualFold(actual, v) })
+	},
+	StringLike: func(actual string, present bool, values Values) bool {
+		return present && anyValue(values, func(v string) bool { return Match(v, actual) })
+	},
+	StringNotLike: func(actual string, present bool, values Values) bool {
+		return !present || !anyValue(values, func(v string) bool { return Match(v, actual) })
+	},
+	NumericEquals:            numeric(func(a, b float64) bool { return a == b }),
+	NumericNotEquals:         numeric(func(a, b float64) bool { return a != b }),
+	NumericLessThan:          numeric(func(a, b float64) bool { return a < b }),
+	NumericLessThanEquals:    numeric(func(a, b float64) bool { return a <= b }),
+	NumericGreaterThan:       numeric(func(a, b float64) bool { return a > b }),
+	NumericGreaterThanEquals: numeric(func(a, b float64) bool { return a >= b }),
+	Bool: func(actual string, present bool, values Values) bool {
+		if !present {
+			return false
+		}
+		a, err := strconv.ParseBool(actual)
+		if err != nil {
+			return false
+		}
+		return anyValue(values, func(v string) bool {
+			b, _ := strconv.ParseBool(v)
+			return a == b
+		})
+	},
+	Null: func(actual string, present bool, values Values) bool {
+		return anyValue(values, func(v string) bool {
+			b, _ := strconv.ParseBool(v)
+			return b != present
+		})
+	},
+}
+
+func numeric(compare func(a, b float64) bool) operator {
+	return func(actual string, present bool, values Values) bool {
+		if !present {
+			return false
+		}
+		a, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return false
+		}
+		return anyValue(values, func(v string) bool {
+			b, _ := strconv.ParseFloat(v, 64)
+			return compare(a, b)
+		})
+	}
+}
+
+func anyValue(values Values, fn func(v string) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// 校验条件值格式是否与操作符匹配
+func checkValues(operator string, values Values) error {
+	for _, v := range values {
+		switch {
+		case strings.HasPrefix(operator, "Numeric"):
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return fmt.Errorf("value '%s' expect number", v)
+			}
+		case operator == Bool || operator == Null:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return fmt.Errorf("value '%s' expect bool", v)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package policy
+
+import "sort"
+
+// Request 鉴权请求
+type Request struct {
+	Action   string            // Action 权限名称，形如 分组:方法名
+	Type     string            // Action 类型
+	Resource string            // 资源名称
+	Scope    map[string]string // 资源范围变量取值
+}
+
+// Decision 鉴权结果，显式拒绝优先，未匹配任何允许语句时隐式拒绝
+type Decision struct {
+	Allowed    bool               `json:"allowed"`
+	Effect     Effect             `json:"effect,omitempty"` // 最终生效的语句效果，隐式拒绝时为空
+	Statements []*StatementResult `json:"statements,omitempty"`
+}
+
+// StatementResult Action 与资源均匹配的语句的求值结果
+type StatementResult struct {
+	Policy     string             `json:"policy,omitempty"`
+	Sid        string             `json:"sid,omitempty"`
+	Effect     Effect             `json:"effect"`
+	Matched    bool               `json:"matched"` // 条件是否全部通过
+	Conditions []*ConditionResult `json:"conditions,omitempty"`
+}
+
+// ConditionResult 单个条件变量的求值结果
+type ConditionResult struct {
+	Operator string `json:"operator"`
+	Var      string `json:"var"`
+	Values   Values `json:"values"`
+	Actual   string `json:"actual,omitempty"`
+	Present  bool   `json:"present"`
+	Passed   bool   `json:"passed"`
+}
+
+// Evaluate 按策略文档对请求求值
+func Evaluate(docs []*Document, req *Request) (decision *Decision) {
+	decision = new(Decision)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, statement := range doc.Statements {
+			result := statement.evaluate(req)
+			if result == nil {
+				continue
+			}
+			result.Policy = doc.Name
+			decision.Statements = append(decision.Statements, result)
+			if !result.Matched {
+				continue
+			}
+			switch statement.Effect {
+			case Deny:
+				decision.Effect = Deny
+			case Allow:
+				if decision.Effect != Deny {
+					decision.Effect = Allow
+				}
+			}
+		}
+	}
+	decision.Allowed = decision.Effect == Allow
+	return
+}
+
+// 语句的 Action、类型或资源不匹配时返回 nil
+func (p Statement) evaluate(req *Request) (result *StatementResult) {
+	if !matchAny(p.Actions, req.Action) {
+		return
+	}
+	if len(p.Types) > 0 && !containsString(p.Types, req.Type) {
+		return
+	}
+	if !matchAny(p.Resources, req.Resource) {
+		return
+	}
+	result = &StatementResult{Sid: p.Sid, Effect: p.Effect, Matched: true}
+	operatorNames := make([]string, 0, len(p.Conditions))
+	for k := range p.Conditions {
+		operatorNames = append(operatorNames, k)
+	}
+	sort.Strings(operatorNames)
+	for _, name := range operatorNames {
+		vars := p.Conditions[name]
+		varNames := make([]string, 0, len(vars))
+		for k := range vars {
+			varNames = append(varNames, k)
+		}
+		sort.Strings(varNames)
+		for _, v := range varNames {
+			condition := &ConditionResult{Operator: name, Var: v, Values: vars[v]}
+			condition.Actual, condition.Present = req.Scope[v]
+			if fn, ok := operators[name]; ok {
+				condition.Passed = fn(condition.Actual, condition.Present, condition.Values)
+			}
+			if !condition.Passed {
+				result.Matched = false
+			}
+			result.Conditions = append(result.Conditions, condition)
+		}
+	}
+	return
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
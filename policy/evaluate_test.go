@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "shop/42/cate/7", true},
+		{"shop/*", "shop/42/cate/7", true},
+		{"shop/*/cate/7", "shop/42/cate/7", true},
+		{"shop/*/cate/7", "shop/42/cate/8", false},
+		{"shop/4?", "shop/42", true},
+		{"shop/4?", "shop/420", false},
+		{"shop:Get*", "shop:GetShop", true},
+		{"shop:Get*", "order:GetShop", false},
+		{"shop", "shop/42", false},
+	} {
+		assert.Equal(t, c.want, Match(c.pattern, c.s), "%s %s", c.pattern, c.s)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	allowShop := &Statement{Sid: "allow-shop", Effect: Allow, Actions: []string{"shop:*"}, Resources: []string{"shop/*"}}
+	denyDelete := &Statement{Sid: "deny-delete", Effect: Deny, Actions: []string{"shop:Delete*"}, Resources: []string{"*"}}
+	readOnly := &Statement{Sid: "read-only", Effect: Allow, Actions: []string{"*"}, Types: []string{"read", "list"}, Resources: []string{"*"}}
+	redOnly := &Statement{Sid: "red-only", Effect: Allow, Actions: []string{"shop:*"}, Resources: []string{"shop/*"}, Conditions: Conditions{
+		StringEquals: {"$color": {"red", "pink"}},
+	}}
+	cheap := &Statement{Sid: "cheap", Effect: Allow, Actions: []string{"shop:*"}, Resources: []string{"shop/*"}, Conditions: Conditions{
+		NumericLessThan: {"$price": {"1234567"}},
+	}}
+	for _, c := range []struct {
+		name       string
+		statements []*Statement
+		req        Request
+		allowed    bool
+		effect     Effect
+	}{
+		{"implicit deny", []*Statement{allowShop}, Request{Action: "order:GetOrder", Resource: "order/1"}, false, ""},
+		{"allow wildcard", []*Statement{allowShop}, Request{Action: "shop:GetShop", Resource: "shop/42/cate/7"}, true, Allow},
+		{"resource mismatch", []*Statement{allowShop}, Request{Action: "shop:GetShop", Resource: "order/1"}, false, ""},
+		{"explicit deny wins", []*Statement{allowShop, denyDelete}, Request{Action: "shop:DeleteShop", Resource: "shop/42"}, false, Deny},
+		{"deny order independent", []*Statement{denyDelete, allowShop}, Request{Action: "shop:DeleteShop", Resource: "shop/42"}, false, Deny},
+		{"deny other action", []*Statement{allowShop, denyDelete}, Request{Action: "shop:GetShop", Resource: "shop/42"}, true, Allow},
+		{"type allowed", []*Statement{readOnly}, Request{Action: "shop:ListShop", Type: "list"}, true, Allow},
+		{"type denied", []*Statement{readOnly}, Request{Action: "shop:AddShop", Type: "write"}, false, ""},
+		{"condition passed", []*Statement{redOnly}, Request{Action: "shop:GetShop", Resource: "shop/42", Scope: map[string]string{"$color": "pink"}}, true, Allow},
+		{"condition failed", []*Statement{redOnly}, Request{Action: "shop:GetShop", Resource: "shop/42", Scope: map[string]string{"$color": "blue"}}, false, ""},
+		{"condition missing", []*Statement{redOnly}, Request{Action: "shop:GetShop", Resource: "shop/42"}, false, ""},
+		{"numeric condition", []*Statement{cheap}, Request{Action: "shop:GetShop", Resource: "shop/42", Scope: map[string]string{"$price": "1234566"}}, true, Allow},
+		{"numeric condition failed", []*Statement{cheap}, Request{Action: "shop:GetShop", Resource: "shop/42", Scope: map[string]string{"$price": "1234567"}}, false, ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			decision := Evaluate([]*Document{{Version: Version, Name: "test", Statements: c.statements}}, &c.req)
+			assert.Equal(t, c.allowed, decision.Allowed)
+			assert.Equal(t, c.effect, decision.Effect)
+		})
+	}
+}
+
+func TestEvaluateConditionResults(t *testing.T) {
+	doc := &Document{Version: Version, Name: "shop", Statements: []*Statement{{
+		Sid:       "scoped",
+		Effect:    Allow,
+		Actions:   []string{"*"},
+		Resources: []string{"*"},
+		Conditions: Conditions{
+			StringEquals: {"$color": {"red"}},
+			Null:         {"$owner": {"true"}},
+		},
+	}}}
+	decision := Evaluate([]*Document{nil, doc}, &Request{Action: "shop:GetShop", Scope: map[string]string{"$color": "blue"}})
+	assert.False(t, decision.Allowed)
+	if assert.Len(t, decision.Statements, 1) {
+		result := decision.Statements[0]
+		assert.Equal(t, "shop", result.Policy)
+		assert.False(t, result.Matched)
+		assert.Equal(t, []*ConditionResult{
+			{Operator: Null, Var: "$owner", Values: Values{"true"}, Present: false, Passed: true},
+			{Operator: StringEquals, Var: "$color", Values: Values{"red"}, Actual: "blue", Present: true, Passed: false},
+		}, result.Conditions)
+	}
+}
@@ -0,0 +1,37 @@
+package policy
+
+// Match 通配符匹配，* 匹配任意字符序列（含 /），? 匹配单个字符
+func Match(pattern, s string) bool {
+	p, n := []rune(pattern), []rune(s)
+	pi, ni := 0, 0
+	star, mark := -1, 0
+	for ni < len(n) {
+		if pi < len(p) && (p[pi] == '?' || p[pi] == n[ni]) {
+			pi++
+			ni++
+		} else if pi < len(p) && p[pi] == '*' {
+			star = pi
+			mark = ni
+			pi++
+		} else if star != -1 {
+			pi = star + 1
+			mark++
+			ni = mark
+		} else {
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, v := range patterns {
+		if Match(v, s) {
+			return true
+		}
+	}
+	return false
+}
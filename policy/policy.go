@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const Version = "1"
+
+const (
+	Allow Effect = "Allow"
+	Deny  Effect = "Deny"
+)
+
+type Effect string
+
+// Document 策略文档
+type Document struct {
+	Version    string       `json:"version" yaml:"version"`
+	Name       string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Statements []*Statement `json:"statements" yaml:"statements"`
+}
+
+// Statement 策略语句，Actions、Types、Resources、Conditions 同时满足时生效
+type Statement struct {
+	Sid        string     `json:"sid,omitempty" yaml:"sid,omitempty"`
+	Effect     Effect     `json:"effect" yaml:"effect"`
+	Actions    []string   `json:"actions" yaml:"actions"`                           // Action 权限名称，形如 分组:方法名，支持 * 通配
+	Types      []string   `json:"types,omitempty" yaml:"types,omitempty"`           // Action 类型，如 read、write、list，为空时不限制
+	Resources  []string   `json:"resources" yaml:"resources"`                       // 资源名称，形如 shop/*/cate/7，支持 * 通配
+	Conditions Conditions `json:"conditions,omitempty" yaml:"conditions,omitempty"` // 资源范围变量条件
+}
+
+// Conditions 条件，结构为 操作符 -> 变量 -> 取值
+type Conditions map[string]map[string]Values
+
+// Values 条件取值，支持单值或数组
+type Values []string
+
+func (v *Values) UnmarshalJSON(data []byte) (err error) {
+	data = bytes.TrimSpace(data)
+	var items []json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err = json.Unmarshal(data, &items); err != nil {
+			return
+		}
+	} else {
+		items = []json.RawMessage{data}
+	}
+	values := make(Values, 0, len(items))
+	for _, item := range items {
+		var value string
+		if value, err = scalarValue(item); err != nil {
+			return
+		}
+		values = append(values, value)
+	}
+	*v = values
+	return
+}
+
+// 取 JSON 标量的字面值，数值保留原文，避免经 float64 转换为科学计数法，如 1234567 转为 1.234567e+06
+func scalarValue(data []byte) (value string, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var item interface{}
+	if err = decoder.Decode(&item); err != nil {
+		return
+	}
+	switch v := item.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("condition value %s expect string, number or bool", data)
+}
+
+// UnmarshalYAML 以字符串接收标量，保留数值的字面值
+func (v *Values) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var list []string
+	if err = unmarshal(&list); err == nil {
+		*v = list
+		return
+	}
+	var item string
+	if err = unmarshal(&item); err != nil {
+		return
+	}
+	*v = Values{item}
+	return
+}
+
+// Parse 解析并校验 JSON 策略文档
+func Parse(data []byte) (doc *Document, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	doc = new(Document)
+	if err = decoder.Decode(doc); err != nil {
+		err = fmt.Errorf("parse policy error: %s", err)
+		return
+	}
+	if err = doc.Validate(); err != nil {
+		return
+	}
+	return
+}
+
+// Validate 校验策略文档
+func (p Document) Validate() error {
+	if p.Version != Version {
+		return fmt.Errorf("policy version '%s' unsupported, expect '%s'", p.Version, Version)
+	}
+	if len(p.Statements) == 0 {
+		return fmt.Errorf("policy statements empty")
+	}
+	for i, v := range p.Statements {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("statement[%d] %s", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate 校验策略语句
+func (p Statement) Validate() error {
+	if p.Effect != Allow && p.Effect != Deny {
+		return fmt.Errorf("effect '%s' unsupported, expect '%s' or '%s'", p.Effect, Allow, Deny)
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("actions empty")
+	}
+	for _, v := range p.Actions {
+		if v == "" {
+			return fmt.Errorf("action empty")
+		}
+	}
+	for _, v := range p.Types {
+		switch v {
+		case "read", "write", "list":
+		default:
+			return fmt.Errorf("type '%s' unsupported", v)
+		}
+	}
+	if len(p.Resources) == 0 {
+		return fmt.Errorf("resources empty")
+	}
+	for _, v := range p.Resources {
+		if v == "" {
+			return fmt.Errorf("resource empty")
+		}
+	}
+	for operator, vars := range p.Conditions {
+		if _, ok := operators[operator]; !ok {
+			return fmt.Errorf("condition operator '%s' unsupported", operator)
+		}
+		for name, values := range vars {
+			if !strings.HasPrefix(name, "$") {
+				return fmt.Errorf("condition variable '%s' expect prefix '$'", name)
+			}
+			if len(values) == 0 {
+				return fmt.Errorf("condition '%s' variable '%s' values empty", operator, name)
+			}
+			if err := checkValues(operator, values); err != nil {
+				return fmt.Errorf("condition '%s' variable '%s' %s", operator, name, err)
+			}
+		}
+	}
+	return nil
+}
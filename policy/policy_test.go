@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestValuesUnmarshalJSON(t *testing.T) {
+	for _, c := range []struct {
+		data string
+		want Values
+		err  bool
+	}{
+		{`"red"`, Values{"red"}, false},
+		{`["red", "blue"]`, Values{"red", "blue"}, false},
+		{`1234567`, Values{"1234567"}, false},
+		{`[1234567, 1.50, 1e3]`, Values{"1234567", "1.50", "1e3"}, false},
+		{`true`, Values{"true"}, false},
+		{`null`, nil, true},
+		{`{"a": 1}`, nil, true},
+		{`[[1]]`, nil, true},
+	} {
+		t.Run(c.data, func(t *testing.T) {
+			var v Values
+			err := json.Unmarshal([]byte(c.data), &v)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, v)
+		})
+	}
+}
+
+func TestValuesUnmarshalYAML(t *testing.T) {
+	for _, c := range []struct {
+		data string
+		want Values
+	}{
+		{`red`, Values{"red"}},
+		{`[red, blue]`, Values{"red", "blue"}},
+		{`1234567`, Values{"1234567"}},
+		{`[1234567, 1.50, 0x1F]`, Values{"1234567", "1.50", "0x1F"}},
+		{`true`, Values{"true"}},
+	} {
+		t.Run(c.data, func(t *testing.T) {
+			var v Values
+			assert.NoError(t, yaml.Unmarshal([]byte(c.data), &v))
+			assert.Equal(t, c.want, v)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"valid", `{"version":"1","statements":[{"effect":"Allow","actions":["shop:*"],"resources":["shop/*"],"conditions":{"NumericLessThan":{"$price":1234567}}}]}`, ""},
+		{"version", `{"version":"2","statements":[{"effect":"Allow","actions":["*"],"resources":["*"]}]}`, "policy version '2' unsupported, expect '1'"},
+		{"empty", `{"version":"1","statements":[]}`, "policy statements empty"},
+		{"unknown field", `{"version":"1","statement":[]}`, `parse policy error: json: unknown field "statement"`},
+		{"effect", `{"version":"1","statements":[{"effect":"Maybe","actions":["*"],"resources":["*"]}]}`, "statement[0] effect 'Maybe' unsupported, expect 'Allow' or 'Deny'"},
+		{"actions", `{"version":"1","statements":[{"effect":"Allow","resources":["*"]}]}`, "statement[0] actions empty"},
+		{"type", `{"version":"1","statements":[{"effect":"Allow","actions":["*"],"types":["delete"],"resources":["*"]}]}`, "statement[0] type 'delete' unsupported"},
+		{"resources", `{"version":"1","statements":[{"effect":"Allow","actions":["*"]}]}`, "statement[0] resources empty"},
+		{"operator", `{"version":"1","statements":[{"effect":"Allow","actions":["*"],"resources":["*"],"conditions":{"Like":{"$color":"red"}}}]}`, "statement[0] condition operator 'Like' unsupported"},
+		{"variable", `{"version":"1","statements":[{"effect":"Allow","actions":["*"],"resources":["*"],"conditions":{"StringEquals":{"color":"red"}}}]}`, "statement[0] condition variable 'color' expect prefix '$'"},
+		{"numeric", `{"version":"1","statements":[{"effect":"Allow","actions":["*"],"resources":["*"],"conditions":{"NumericEquals":{"$price":"cheap"}}}]}`, "statement[0] condition 'NumericEquals' variable '$price' value 'cheap' expect number"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := Parse([]byte(c.data))
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, Values{"1234567"}, doc.Statements[0].Conditions[NumericLessThan]["$price"])
+			}
+		})
+	}
+}
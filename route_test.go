@@ -0,0 +1,67 @@
+package iam
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/exporter"
+)
+
+func newRouteAPI() *API {
+	service := new(testShopService)
+	api := New()
+	api.SetVersion("1.0.0")
+	api.AddRouter(testRouter{{Prefix: "/api", Groups: []*Group{{Name: "shop", Prefix: "/shop", Actions: []*Action{
+		{Type: Read, Resources: []Resource{testShopResource}, Handler: service.GetShop},
+		{Type: Write, Path: "/upload", Handler: service.Upload},
+	}}}}})
+	return api
+}
+
+func TestRouteTable(t *testing.T) {
+	api := newRouteAPI()
+	_, err := api.Handler()
+	require.NoError(t, err)
+	rows := api.RouteTable().Rows()
+	require.Len(t, rows, 2)
+	for i := range rows {
+		assert.NotEmpty(t, rows[i].Location)
+		rows[i].Location = ""
+	}
+	assert.Equal(t, []RouteRow{
+		{Method: http.MethodGet, Path: "/api/shop/GetShop", Source: "GetShop", Permission: "shop:GetShop", Type: "read", Resource: "shop/$shopId"},
+		{Method: http.MethodPost, Path: "/api/shop/upload", Source: "Upload", Permission: "shop:Upload", Type: "write"},
+	}, rows)
+}
+
+func TestRoutesEndpoint(t *testing.T) {
+	api := newRouteAPI()
+	api.SetExporter("", nil)
+	api.SetExporterPrefix("_")
+	w := serve(t, api, httptest.NewRequest(http.MethodGet, "/_/routes", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	out := new(exporter.RoutesOutput)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+	assert.Equal(t, "1.0.0", out.Version)
+	require.Len(t, out.Routes, 2)
+	assert.Equal(t, &exporter.Route{
+		Method:     http.MethodGet,
+		Path:       "/api/shop/GetShop",
+		Handler:    "GetShop",
+		Location:   out.Routes[0].Location,
+		Permission: "shop:GetShop",
+		Type:       "read",
+		Resource:   "shop/$shopId",
+	}, out.Routes[0])
+	assert.Equal(t, "shop:Upload", out.Routes[1].Permission)
+
+	api = newTestAPI("shop")
+	api.SetExporter("", nil)
+	api.SetExporterPrefix("_")
+	w = serve(t, api, httptest.NewRequest(http.MethodGet, "/_/routes", nil))
+	assert.JSONEq(t, `{"version":"","routes":[]}`, w.Body.String())
+}
@@ -0,0 +1,160 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/utilslab/iam/exporter"
+	"github.com/utilslab/iam/policy"
+	"reflect"
+)
+
+// Explainer 可解释的鉴权器，返回包含匹配语句与条件的完整鉴权结果
+type Explainer interface {
+	Evaluate(ctx context.Context, req *AuthRequest) (*policy.Decision, error)
+}
+
+var _ Explainer = new(PolicyAuthorizer)
+
+// PrincipalLoader 按调用者标识构造鉴权上下文，用于鉴权模拟
+type PrincipalLoader func(ctx context.Context, principal string) (context.Context, error)
+
+// SimulateRequest 鉴权模拟请求
+type SimulateRequest struct {
+	Principal string          `json:"principal"` // 调用者标识，由 PrincipalLoader 解析
+	Action    string          `json:"action"`    // 权限名称（分组:方法名）或方法名
+	Input     json.RawMessage `json:"input"`     // 样例入参
+}
+
+// SimulateResult 鉴权模拟结果
+type SimulateResult struct {
+	Permission string            `json:"permission"`
+	Type       ActionType        `json:"type"`
+	Resource   string            `json:"resource"`
+	Scope      map[string]string `json:"scope,omitempty"`
+	Decision   *policy.Decision  `json:"decision"`
+}
+
+func (p *API) SetPrincipalLoader(loader PrincipalLoader) {
+	p.principalLoader = loader
+}
+
+// SimulatePermission 调用导出器 POST /simulate 接口所需的权限名称
+const SimulatePermission = "iam:Simulate"
+
+// SetSimulateEndpoint 设置是否在导出器上开放 POST /simulate 接口，默认关闭
+//
+// 开放后调用者上下文由 ContextWrapper 构造，设置鉴权器时需具备 SimulatePermission 权限，该接口不允许跨域调用
+func (p *API) SetSimulateEndpoint(enabled bool) {
+	p.simulateEndpoint = enabled
+}
+
+// Simulate 模拟一次鉴权，不调用 Handler，返回解析的资源名称、范围变量以及鉴权结果
+func (p *API) Simulate(ctx context.Context, req *SimulateRequest) (result *SimulateResult, err error) {
+	action, err := p.findAction(req.Action)
+	if err != nil {
+		return
+	}
+	if req.Principal != "" {
+		if p.principalLoader == nil {
+			err = fmt.Errorf("principal loader not defined")
+			return
+		}
+		ctx, err = p.principalLoader(ctx, req.Principal)
+		if err != nil {
+			return
+		}
+	}
+	var in reflect.Value
+	var keys map[string]json.RawMessage
+	if action.handler.Type().NumIn() == 2 {
+		in = reflect.New(realType(action.handler.Type().In(1)))
+		if len(req.Input) > 0 {
+			if err = json.Unmarshal(req.Input, in.Interface()); err != nil {
+				err = fmt.Errorf("decode input error: %s", err)
+				return
+			}
+			if err = json.Unmarshal(req.Input, &keys); err != nil {
+				err = fmt.Errorf("decode input error: %s", err)
+				return
+			}
+		}
+	}
+	authRequest, err := newAuthRequest(action, in, jsonPresence(keys))
+	if err != nil {
+		return
+	}
+	result = &SimulateResult{
+		Permission: authRequest.Permission(),
+		Type:       authRequest.Type,
+		Resource:   authRequest.Resource,
+		Scope:      authRequest.Scope,
+	}
+	switch authorizer := p.authorizer.(type) {
+	case nil:
+		result.Decision = &policy.Decision{Allowed: true}
+	case Explainer:
+		result.Decision, err = authorizer.Evaluate(ctx, authRequest)
+	default:
+		result.Decision = new(policy.Decision)
+		result.Decision.Allowed, err = authorizer.Authorize(ctx, authRequest)
+	}
+	return
+}
+
+// 按权限名称或方法名查找 Action，方法名重复时需使用权限名称
+func (p *API) findAction(name string) (action *Action, err error) {
+	var matched []*Action
+	for _, v := range p.actions {
+		if permissionName(v.group, v.name) == name {
+			return v, nil
+		}
+		if v.name == name {
+			matched = append(matched, v)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		err = fmt.Errorf("action '%s' not found", name)
+	case 1:
+		action = matched[0]
+	default:
+		err = fmt.Errorf("action '%s' ambiguous, use permission name instead", name)
+	}
+	return
+}
+
+// 供 API 导出器调用的鉴权模拟器，先对调用者执行 SimulatePermission 鉴权
+func (p *API) simulator(c *gin.Context, data []byte) (interface{}, error) {
+	ctx, err := p.callerContext(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", exporter.ErrSimulateDenied, err)
+	}
+	if p.authorizer != nil {
+		req := &AuthRequest{Group: "iam", Action: "Simulate", Type: Read, Scope: map[string]string{}}
+		allowed, err := p.authorizer.Authorize(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", exporter.ErrSimulateDenied, err)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: permission '%s' required", exporter.ErrSimulateDenied, SimulatePermission)
+		}
+	}
+	req := new(SimulateRequest)
+	if err = json.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("decode simulate request error: %s", err)
+	}
+	return p.Simulate(ctx, req)
+}
+
+// 按驱动的上下文包装器构造调用者上下文
+func (p *API) callerContext(c *gin.Context) (context.Context, error) {
+	if p.contextWrapper != nil {
+		return p.contextWrapper(c)
+	}
+	if driver, ok := p.driver.(*HTTPDriver); ok && driver.contextWrapper != nil {
+		return driver.contextWrapper(c.Request)
+	}
+	return c.Request.Context(), nil
+}
@@ -0,0 +1,120 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/utilslab/iam/policy"
+)
+
+type testPrincipalKey struct{}
+
+// 按调用者名称返回策略，admin 可模拟鉴权，其余调用者仅可访问红色店铺
+func testPolicySource(ctx context.Context) ([]*policy.Document, error) {
+	principal, _ := ctx.Value(testPrincipalKey{}).(string)
+	statements := []*policy.Statement{{
+		Sid:        "red-shop",
+		Effect:     policy.Allow,
+		Actions:    []string{"shop:*"},
+		Resources:  []string{"shop/*"},
+		Conditions: policy.Conditions{policy.StringEquals: {"$color": {"red"}}},
+	}}
+	if principal == "admin" {
+		statements = append(statements, &policy.Statement{Sid: "simulate", Effect: policy.Allow, Actions: []string{SimulatePermission}, Resources: []string{"*"}})
+	}
+	if principal == "" {
+		return nil, nil
+	}
+	return []*policy.Document{{Version: policy.Version, Name: principal, Statements: statements}}, nil
+}
+
+func newSimulateAPI() *API {
+	service := new(testShopService)
+	api := newTestAPI("shop", &Action{Type: Read, Resources: []Resource{testShopResource}, Handler: service.GetShop})
+	api.SetAuthorizer(NewPolicyAuthorizer(testPolicySource))
+	api.SetPrincipalLoader(func(ctx context.Context, principal string) (context.Context, error) {
+		return context.WithValue(ctx, testPrincipalKey{}, principal), nil
+	})
+	api.SetContextWrapper(func(c *gin.Context) (context.Context, error) {
+		return context.WithValue(c.Request.Context(), testPrincipalKey{}, c.GetHeader("X-Principal")), nil
+	})
+	return api
+}
+
+func TestSimulate(t *testing.T) {
+	api := newSimulateAPI()
+	require.NoError(t, api.Validate())
+	for _, c := range []struct {
+		name     string
+		req      SimulateRequest
+		allowed  bool
+		resource string
+		err      string
+	}{
+		{"allowed", SimulateRequest{Principal: "u1", Action: "shop:GetShop", Input: json.RawMessage(`{"shopId":42,"color":"red"}`)}, true, "shop/42", ""},
+		{"by handler name", SimulateRequest{Principal: "u1", Action: "GetShop", Input: json.RawMessage(`{"shopId":42,"color":"red"}`)}, true, "shop/42", ""},
+		{"condition failed", SimulateRequest{Principal: "u1", Action: "shop:GetShop", Input: json.RawMessage(`{"shopId":42,"color":"blue"}`)}, false, "shop/42", ""},
+		{"zero identifier", SimulateRequest{Principal: "u1", Action: "shop:GetShop", Input: json.RawMessage(`{"shopId":0,"color":"red"}`)}, true, "shop/0", ""},
+		{"anonymous", SimulateRequest{Action: "shop:GetShop", Input: json.RawMessage(`{"shopId":42,"color":"red"}`)}, false, "shop/42", ""},
+		{"unknown action", SimulateRequest{Action: "shop:AddShop"}, false, "", "action 'shop:AddShop' not found"},
+		{"identifier missing", SimulateRequest{Action: "shop:GetShop"}, false, "", "resource 'shop' identifier missing"},
+		{"invalid input", SimulateRequest{Action: "shop:GetShop", Input: json.RawMessage(`[]`)}, false, "", "decode input error: json: cannot unmarshal array into Go value of type iam.testShopIn"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := api.Simulate(context.Background(), &c.req)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "shop:GetShop", result.Permission)
+			assert.Equal(t, c.resource, result.Resource)
+			assert.Equal(t, c.allowed, result.Decision.Allowed)
+		})
+	}
+}
+
+func TestSimulateEndpoint(t *testing.T) {
+	body := `{"principal":"u1","action":"shop:GetShop","input":{"shopId":42,"color":"red"}}`
+	for _, c := range []struct {
+		name       string
+		enabled    bool
+		authorizer bool
+		caller     string
+		status     int
+	}{
+		{"disabled", false, true, "admin", http.StatusNotFound},
+		{"no authorizer", true, false, "", http.StatusOK},
+		{"anonymous", true, true, "", http.StatusForbidden},
+		{"not permitted", true, true, "u1", http.StatusForbidden},
+		{"permitted", true, true, "admin", http.StatusOK},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			api := newSimulateAPI()
+			if !c.authorizer {
+				api.SetAuthorizer(nil)
+			}
+			api.SetSimulateEndpoint(c.enabled)
+			api.SetExporter("", nil)
+			api.SetExporterPrefix("_")
+			r := httptest.NewRequest(http.MethodPost, "/_/simulate", strings.NewReader(body))
+			r.Header.Set("X-Principal", c.caller)
+			r.Header.Set("Origin", "http://example.com")
+			w := serve(t, api, r)
+			assert.Equal(t, c.status, w.Code, w.Body.String())
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			if c.status == http.StatusOK {
+				result := new(SimulateResult)
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+				assert.Equal(t, "shop/42", result.Resource)
+			}
+		})
+	}
+}
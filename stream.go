@@ -0,0 +1,232 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/utilslab/iam/internal/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CodeOriginDenied WebSocket 请求的 Origin 未通过检查时返回的错误码，状态为 403
+const CodeOriginDenied = "OriginDenied"
+
+const (
+	wsWriteWait  = 10 * time.Second    // 单次写入超时
+	wsPongWait   = 60 * time.Second    // 等待客户端 pong 的超时
+	wsPingPeriod = wsPongWait * 9 / 10 // 发送 ping 的间隔，须小于 wsPongWait
+)
+
+// 检查输出是否为事件流 <-chan T
+func isStream(t reflect.Type) bool {
+	return t.Kind() == reflect.Chan && t.ChanDir()&reflect.RecvDir != 0
+}
+
+// 事件流 Action 的 ctx 随客户端断开而取消，服务方法应在 ctx 结束后关闭 channel
+func streamContext(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-r.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// SetWebSocketCheckOrigin 设置 WebSocket 请求的 Origin 检查，未设置时仅允许与 Host 同源的请求
+func (p *API) SetWebSocketCheckOrigin(checkOrigin func(r *http.Request) bool) {
+	p.checkOrigin = checkOrigin
+}
+
+// 检查是否为 WebSocket 升级请求
+func isWebSocket(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r)
+}
+
+// 在调用 Handler 前检查 WebSocket 请求的 Origin，未通过时返回 403
+func (p *API) checkWebSocketOrigin(r *http.Request) error {
+	if p.upgrader().CheckOrigin(r) {
+		return nil
+	}
+	return &CodedError{Status: http.StatusForbidden, Code: CodeOriginDenied, Message: fmt.Sprintf("origin '%s' not allowed", r.Header.Get("Origin"))}
+}
+
+func (p *API) upgrader() *websocket.Upgrader {
+	upgrader := &websocket.Upgrader{CheckOrigin: p.checkOrigin}
+	if upgrader.CheckOrigin == nil {
+		upgrader.CheckOrigin = sameOrigin
+	}
+	return upgrader
+}
+
+// 与 gorilla 默认规则一致：无 Origin 或 Origin 的 Host 与请求 Host 相同
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// 输出事件流，WebSocket 升级请求以 WebSocket 推送，否则以 SSE 推送
+func (p *API) writeEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, events interface{}) error {
+	ch := reflect.ValueOf(events)
+	if !ch.IsValid() || ch.IsNil() {
+		return fmt.Errorf("event channel is nil")
+	}
+	if isWebSocket(r) {
+		return p.writeWebSocket(ctx, w, r, ch)
+	}
+	return writeSSE(ctx, w, ch)
+}
+
+// 逐条接收事件，ctx 结束或 channel 关闭时返回
+func receiveEvents(ctx context.Context, ch reflect.Value, send func(data []byte) error) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	}
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		if chosen == 0 {
+			return nil
+		}
+		if !ok {
+			return io.EOF
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		if err = send(data); err != nil {
+			return err
+		}
+	}
+}
+
+// SSE：每个事件以 data 输出 JSON，channel 关闭时输出 end 事件，出错时输出 error 事件
+func writeSSE(ctx context.Context, w http.ResponseWriter, ch reflect.Value) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("response writer does not support flush")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	err := receiveEvents(ctx, ch, func(data []byte) error {
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	switch err {
+	case nil:
+	case io.EOF:
+		_, _ = fmt.Fprint(w, "event: end\ndata: \n\n")
+	default:
+		_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	flusher.Flush()
+	return nil
+}
+
+// WebSocket：仅由服务端推送，每个事件以文本帧输出 JSON，定时发送 ping 并等待 pong，
+// channel 关闭时以正常关闭帧结束，出错时以 1011 关闭帧携带错误信息，客户端断开或 pong 超时时结束推送
+func (p *API) writeWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request, ch reflect.Value) error {
+	conn, err := p.upgrader().Upgrade(w, r, nil)
+	if err != nil {
+		// 握手失败时 Upgrader 已输出错误响应
+		return nil
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 读取客户端帧以处理 pong 与关闭帧，读取出错或超时时结束推送
+	go func() {
+		defer cancel()
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	events := make(chan []byte)
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveEvents(ctx, ch, func(data []byte) error {
+			select {
+			case events <- data:
+				return nil
+			case <-ctx.Done():
+				return nil
+			}
+		})
+	}()
+	for {
+		select {
+		case data := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				cancel()
+				<-done
+				return nil
+			}
+		case <-ticker.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				cancel()
+				<-done
+				return nil
+			}
+		case err = <-done:
+			var message []byte
+			switch {
+			case err == nil:
+				// 客户端断开或 ctx 结束
+				message = websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+			case err == io.EOF:
+				message = websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			default:
+				message = websocket.FormatCloseMessage(websocket.CloseInternalServerErr, closeReason(err.Error()))
+			}
+			_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteWait))
+			return nil
+		}
+	}
+}
+
+// 关闭帧的控制载荷不超过 125 字节，扣除 2 字节状态码后截断原因
+func closeReason(reason string) string {
+	const max = 123
+	if len(reason) <= max {
+		return reason
+	}
+	reason = reason[:max]
+	for len(reason) > 0 && !utf8.ValidString(reason) {
+		reason = reason[:len(reason)-1]
+	}
+	return reason
+}
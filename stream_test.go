@@ -0,0 +1,148 @@
+package iam
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Id    int64       `json:"id"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+type testStreamService struct {
+	calls int
+}
+
+func (p *testStreamService) Watch(ctx context.Context) (<-chan *testEvent, error) {
+	p.calls++
+	ch := make(chan *testEvent)
+	go func() {
+		defer close(ch)
+		for i := int64(1); i <= 2; i++ {
+			select {
+			case ch <- &testEvent{Id: i}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// 推送无法编码为 JSON 的事件
+func (p *testStreamService) Broken(ctx context.Context) (<-chan *testEvent, error) {
+	p.calls++
+	ch := make(chan *testEvent, 1)
+	ch <- &testEvent{Id: 1, Value: func() {}}
+	close(ch)
+	return ch, nil
+}
+
+func newStreamServer(t *testing.T, service *testStreamService) (*API, *httptest.Server) {
+	api := newTestAPI("stream",
+		&Action{Type: Read, Handler: service.Watch},
+		&Action{Type: Read, Handler: service.Broken},
+	)
+	handler, err := api.Handler()
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func TestSSE(t *testing.T) {
+	_, server := newStreamServer(t, new(testStreamService))
+	for _, c := range []struct {
+		path string
+		body string
+	}{
+		{"/Watch", "data: {\"id\":1}\n\ndata: {\"id\":2}\n\nevent: end\ndata: \n\n"},
+		{"/Broken", "event: error\ndata: json: unsupported type: func()\n\n"},
+	} {
+		res, err := http.Get(server.URL + c.path)
+		require.NoError(t, err)
+		var b strings.Builder
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			b.WriteString(scanner.Text() + "\n")
+		}
+		_ = res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, c.path)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"), c.path)
+		assert.Equal(t, c.body, b.String(), c.path)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	_, server := newStreamServer(t, new(testStreamService))
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"/Watch", nil)
+	require.NoError(t, err)
+	var events []string
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err.Error())
+			break
+		}
+		events = append(events, string(data))
+	}
+	_ = conn.Close()
+	assert.Equal(t, []string{`{"id":1}`, `{"id":2}`}, events)
+
+	conn, _, err = websocket.DefaultDialer.Dial(url+"/Broken", nil)
+	require.NoError(t, err)
+	_, _, err = conn.ReadMessage()
+	_ = conn.Close()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseInternalServerErr, closeErr.Code)
+	assert.Equal(t, "json: unsupported type: func()", closeErr.Text)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	service := new(testStreamService)
+	api, server := newStreamServer(t, service)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/Watch"
+
+	// 跨域请求在调用服务方法前拒绝
+	header := http.Header{"Origin": {"http://evil.example.com"}}
+	_, res, err := websocket.DefaultDialer.Dial(url, header)
+	assert.Equal(t, websocket.ErrBadHandshake, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Zero(t, service.calls)
+
+	// 同源请求允许
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {server.URL}})
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	api.SetWebSocketCheckOrigin(func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "http://evil.example.com"
+	})
+	conn, _, err = websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	_ = conn.Close()
+	_, res, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": {server.URL}})
+	assert.Equal(t, websocket.ErrBadHandshake, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestCloseReason(t *testing.T) {
+	assert.Equal(t, "short", closeReason("short"))
+	reason := closeReason(strings.Repeat("错", 50))
+	assert.Len(t, reason, 123)
+	assert.Equal(t, strings.Repeat("错", 41), reason)
+}
@@ -7,13 +7,17 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"os"
 	"reflect"
+	"strings"
 )
 
 const (
-	Post   = "POST"
-	Get    = "GET"
-	Delete = "DELETE"
-	Put    = "PUT"
+	Post    = "POST"
+	Get     = "GET"
+	Delete  = "DELETE"
+	Put     = "PUT"
+	Patch   = "PATCH"
+	Head    = "HEAD"
+	Options = "OPTIONS"
 )
 
 const (
@@ -40,9 +44,10 @@ type Register interface {
 }
 
 type Route struct {
-	Prefix      string
-	Middlewares []gin.HandlerFunc `json:"-"`
-	Groups      []*Group
+	Prefix       string
+	Middlewares  []gin.HandlerFunc `json:"-"`
+	Interceptors []Interceptor     `json:"-"`
+	Groups       []*Group
 }
 
 type Router interface {
@@ -70,31 +75,41 @@ type RouteTable struct {
 	rows []RouteRow
 }
 
-func (p *RouteTable) AddRow(method, path string, info HandlerInfo) {
+func (p *RouteTable) AddRow(method, path string, info HandlerInfo, action *Action) {
 	p.rows = append(p.rows, RouteRow{
-		Method:   method,
-		Path:     path,
-		Source:   info.Name,
-		Location: info.Location,
+		Method:     method,
+		Path:       path,
+		Source:     info.Name,
+		Location:   info.Location,
+		Permission: permissionName(action.group, action.name),
+		Type:       string(action.Type),
+		Resource:   resourceTemplate(action.Resources),
 	})
 }
 
+func (p RouteTable) Rows() []RouteRow {
+	return p.rows
+}
+
 func (p RouteTable) Print() {
 	t := tablewriter.NewWriter(os.Stdout)
-	t.SetHeader([]string{"", "接口地址", "方法名", "位置"})
+	t.SetHeader([]string{"", "接口地址", "方法名", "类型", "资源", "位置"})
 	var data [][]string
 	for _, v := range p.rows {
-		data = append(data, []string{v.Method, v.Path, v.Source, v.Location})
+		data = append(data, []string{v.Method, v.Path, v.Source, v.Type, v.Resource, v.Location})
 	}
 	t.AppendBulk(data)
 	t.Render()
 }
 
 type RouteRow struct {
-	Method   string `json:"method"`
-	Path     string `json:"path"`
-	Source   string `json:"source"`
-	Location string `json:"location"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Source     string `json:"source"`
+	Location   string `json:"location"`
+	Permission string `json:"permission"`
+	Type       string `json:"type,omitempty"`
+	Resource   string `json:"resource,omitempty"` // 资源名称模板
 }
 
 type Value struct {
@@ -124,23 +139,38 @@ func (r Resource) Optional() Resource {
 	return n
 }
 
+// 资源名称模板，如 shop/$shopId
+func (r Resource) template() string {
+	segments := []string{r.Name}
+	for _, v := range r.Ident {
+		segments = append(segments, v.Var)
+	}
+	return strings.Join(segments, "/")
+}
+
 type Group struct {
-	Name        string
-	Prefix      string
-	Middlewares []gin.HandlerFunc
-	Actions     []*Action
+	Name         string
+	Prefix       string
+	Middlewares  []gin.HandlerFunc
+	Interceptors []Interceptor
+	Actions      []*Action
 }
 
 type Action struct {
-	Type        ActionType
-	Description string
-	Resources   []Resource
-	Codes       []Code
-	Handler     interface{} `json:"-"`
-	handler     reflect.Value
-	group       string
-	method      string
-	path        string
+	Type         ActionType
+	Method       string // HTTP 方法，未设置时 Read、List 为 GET，其余为 POST
+	Path         string // 路由路径，支持路径参数，如 /shops/:shopId，未设置时为 /<方法名>
+	Description  string
+	Resources    []Resource
+	Codes        []Code
+	Interceptors []Interceptor `json:"-"`
+	Handler      interface{}   `json:"-"`
+	handler      reflect.Value
+	name         string
+	location     string
+	group        string
+	method       string
+	path         string
 }
 
 type Code struct {
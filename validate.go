@@ -0,0 +1,202 @@
+package iam
+
+import (
+	"fmt"
+	"github.com/utilslab/iam/utils"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ValidationError 路由校验报告，汇总启动前发现的全部问题
+type ValidationError struct {
+	Issues []*Issue
+}
+
+type Issue struct {
+	Action   string `json:"action,omitempty"`   // 权限名称
+	Location string `json:"location,omitempty"` // Handler 源码位置
+	Message  string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("route validation failed with %d issue(s):", len(e.Issues)))
+	for _, v := range e.Issues {
+		b.WriteString("\n  - ")
+		if v.Action != "" {
+			b.WriteString(fmt.Sprintf("[%s] ", v.Action))
+		}
+		b.WriteString(v.Message)
+		if v.Location != "" {
+			b.WriteString(fmt.Sprintf(" (%s)", v.Location))
+		}
+	}
+	return b.String()
+}
+
+func (e *ValidationError) add(action *Action, format string, args ...interface{}) {
+	issue := &Issue{Message: fmt.Sprintf(format, args...)}
+	if action != nil {
+		if action.name != "" {
+			issue.Action = permissionName(action.group, action.name)
+		}
+		issue.Location = action.location
+	}
+	e.Issues = append(e.Issues, issue)
+}
+
+// 校验路由冲突、方法名冲突、资源标识变量及路径参数
+func (p *API) validateRoutes(routes []*Route, report *ValidationError) {
+	paths := map[string]*Action{}
+	names := map[string]*Action{}
+	for _, route := range routes {
+		for _, group := range route.Groups {
+			for _, action := range group.Actions {
+				if !action.handler.IsValid() {
+					continue
+				}
+				switch action.method {
+				case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+					http.MethodDelete, http.MethodHead, http.MethodOptions:
+				default:
+					report.add(action, "method '%s' unsupported", action.method)
+				}
+				fullPath := strings.Join([]string{route.Prefix, group.Prefix, action.path}, "")
+				key := fmt.Sprintf("%s %s", action.method, fullPath)
+				if v, ok := paths[key]; ok {
+					report.add(action, "route '%s' conflicts with action '%s'", key, permissionName(v.group, v.name))
+				} else {
+					paths[key] = action
+				}
+				if v, ok := names[action.name]; ok {
+					report.add(action, "handler name '%s' duplicates action '%s', generated SDK methods would clash", action.name, permissionName(v.group, v.name))
+				} else {
+					names[action.name] = action
+				}
+				var in reflect.Type
+				if action.handler.Type().NumIn() == 2 {
+					in = utils.TypeElem(action.handler.Type().In(1))
+				}
+				// 仅检查资源标识变量，范围变量缺失时按未提供处理，由策略条件判断
+				for _, resource := range action.Resources {
+					for _, v := range resource.Ident {
+						if in == nil || !hasVar(in, v.Var) {
+							report.add(action, "resource '%s' identifier '%s' not found in input", resource.Name, v.Var)
+						}
+					}
+				}
+				for _, segment := range strings.Split(fullPath, "/") {
+					if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+						continue
+					}
+					if in == nil || !hasPathParam(in, segment[1:]) {
+						report.add(action, "path param '%s' not found in input uri tags", segment[1:])
+					}
+				}
+				if t := action.handler.Type(); t.NumOut() == 2 && isStream(t.Out(0)) && action.method != http.MethodGet {
+					report.add(action, "event stream requires method GET, got '%s'", action.method)
+				}
+				if in != nil {
+					for _, name := range legacyValidatorFields(in, "", map[reflect.Type]bool{}) {
+						report.add(action, "field '%s' uses legacy 'validator' tag, replace it with 'binding'", name)
+					}
+				}
+				if in != nil && hasFile(in) {
+					switch action.method {
+					case http.MethodPost, http.MethodPut, http.MethodPatch:
+					default:
+						report.add(action, "file input requires method POST, PUT or PATCH, got '%s'", action.method)
+					}
+				}
+			}
+		}
+	}
+}
+
+// 检查入参是否存在与变量匹配的字段
+func hasVar(t reflect.Type, name string) bool {
+	name = strings.TrimPrefix(name, "$")
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			if et := utils.TypeElem(f.Type); et.Kind() == reflect.Struct && hasVar(et, name) {
+				return true
+			}
+			continue
+		}
+		if f.PkgPath == "" && matchVar(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查入参是否存在与路径参数匹配的字段，未设置 uri 标签时按字段名匹配
+func hasPathParam(t reflect.Type, key string) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			if et := utils.TypeElem(f.Type); et.Kind() == reflect.Struct && hasPathParam(et, key) {
+				return true
+			}
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("uri"), ",")[0]
+		if tag == key || (tag == "" && f.Name == key) {
+			return true
+		}
+	}
+	return false
+}
+
+// 查找仍使用旧版 validator 标签的字段，该标签已由 binding 标签取代，不参与运行时校验也不再导出
+func legacyValidatorFields(t reflect.Type, prefix string, visited map[reflect.Type]bool) (names []string) {
+	t = utils.TypeElem(t)
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = utils.TypeElem(t.Elem())
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name := prefix
+		if !f.Anonymous {
+			name += f.Name
+		}
+		if _, ok := f.Tag.Lookup("validator"); ok {
+			names = append(names, name)
+		}
+		if !f.Anonymous {
+			name += "."
+		}
+		names = append(names, legacyValidatorFields(f.Type, name, visited)...)
+	}
+	return
+}
+
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+// 检查入参是否包含上传文件字段
+func hasFile(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		et := utils.TypeElem(f.Type)
+		if et.Kind() == reflect.Slice || et.Kind() == reflect.Array {
+			et = utils.TypeElem(et.Elem())
+		}
+		if et == fileHeaderType {
+			return true
+		}
+		if f.Anonymous && et.Kind() == reflect.Struct && hasFile(et) {
+			return true
+		}
+	}
+	return false
+}
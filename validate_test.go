@@ -0,0 +1,109 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUploadIn struct {
+	ShopId int64                 `json:"shopId" form:"shopId"`
+	Avatar *multipart.FileHeader `form:"avatar"`
+}
+
+func (p *testShopService) Upload(ctx context.Context, in *testUploadIn) (*testShopOut, error) {
+	return &testShopOut{ShopId: in.ShopId}, nil
+}
+
+type testLegacyIn struct {
+	ShopId int64 `json:"shopId" validator:"required"`
+	Items  []*struct {
+		Sku string `json:"sku" validator:"required"`
+	} `json:"items"`
+}
+
+func (p *testShopService) CreateLegacy(ctx context.Context, in *testLegacyIn) (*testShopOut, error) {
+	return &testShopOut{ShopId: in.ShopId}, nil
+}
+
+type testOrderService struct{}
+
+func (p testOrderService) GetShop(ctx context.Context, in *testShopIn) (*testShopOut, error) {
+	return &testShopOut{ShopId: in.ShopId}, nil
+}
+
+// 收集校验问题的信息
+func validateMessages(t *testing.T, api *API) []string {
+	t.Helper()
+	err := api.Validate()
+	if err == nil {
+		return nil
+	}
+	var report *ValidationError
+	require.True(t, errors.As(err, &report), "%v", err)
+	var messages []string
+	for _, v := range report.Issues {
+		messages = append(messages, v.Message)
+	}
+	return messages
+}
+
+func TestValidate(t *testing.T) {
+	service := new(testShopService)
+	ownerResource := Resource{Name: "shop", Ident: []Field{{Var: "$shopId"}}, Scope: []Field{{Var: "$owner"}}}
+	for _, c := range []struct {
+		name    string
+		actions []*Action
+		want    []string
+	}{
+		{"valid", []*Action{{Type: Read, Resources: []Resource{testShopResource, testCateResource}, Handler: service.GetShop}}, nil},
+		{"scope variable optional", []*Action{{Type: Read, Resources: []Resource{ownerResource}, Handler: service.GetShop}}, nil},
+		{"identifier missing", []*Action{{Type: Read, Resources: []Resource{{Name: "good", Ident: []Field{{Var: "$goodId"}}}}, Handler: service.GetShop}}, []string{
+			"resource 'good' identifier '$goodId' not found in input",
+		}},
+		{"route conflict", []*Action{
+			{Type: Read, Path: "/shop", Handler: service.GetShop},
+			{Type: Read, Path: "/shop", Handler: service.Upload},
+		}, []string{
+			"route 'GET /shop' conflicts with action 'shop:GetShop'",
+			"file input requires method POST, PUT or PATCH, got 'GET'",
+		}},
+		{"handler name clash", []*Action{
+			{Type: Read, Handler: service.GetShop},
+			{Type: Read, Path: "/order", Handler: testOrderService{}.GetShop},
+		}, []string{
+			"handler name 'GetShop' duplicates action 'shop:GetShop', generated SDK methods would clash",
+		}},
+		{"path param missing", []*Action{{Type: Read, Path: "/shops/:shopId", Handler: service.GetShop}}, []string{
+			"path param 'shopId' not found in input uri tags",
+		}},
+		{"method unsupported", []*Action{{Method: "TRACE", Handler: service.GetShop}}, []string{
+			"method 'TRACE' unsupported",
+		}},
+		{"legacy validator tag", []*Action{{Type: Write, Handler: service.CreateLegacy}}, []string{
+			"field 'ShopId' uses legacy 'validator' tag, replace it with 'binding'",
+			"field 'Items.Sku' uses legacy 'validator' tag, replace it with 'binding'",
+		}},
+		{"handler missing", []*Action{{Type: Read}}, []string{
+			"action Handler not defined",
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, validateMessages(t, newTestAPI("shop", c.actions...)))
+		})
+	}
+}
+
+func TestValidateGroup(t *testing.T) {
+	api := New()
+	api.AddRouter(testRouter{{Prefix: "/api", Groups: []*Group{{Prefix: "/shop", Actions: []*Action{
+		{Type: Read, Handler: new(testShopService).GetShop},
+	}}}}})
+	// 未声明名称的分组不视为错误，权限名称不带分组前缀
+	assert.Empty(t, validateMessages(t, api))
+	assert.Equal(t, "GetShop", permissionName(api.actions[0].group, api.actions[0].name))
+}